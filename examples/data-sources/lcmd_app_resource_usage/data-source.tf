@@ -0,0 +1,9 @@
+# Copyright (c) HashiCorp, Inc.
+
+data "lcmd_app_resource_usage" "immich" {
+  appid = "cloud.lazycat.app.immich"
+}
+
+output "immich_memory_headroom" {
+  value = data.lcmd_app_resource_usage.immich.memory_limit_bytes - data.lcmd_app_resource_usage.immich.memory_bytes
+}
@@ -0,0 +1,16 @@
+# Copyright (c) HashiCorp, Inc.
+
+data "lcmd_app_store_categories" "store" {}
+
+locals {
+  curated = ["cloud.lazycat.app.jellyfin", "cloud.lazycat.app.immich"]
+}
+
+resource "terraform_data" "validate_curated" {
+  lifecycle {
+    precondition {
+      condition     = alltrue([for appid in local.curated : contains(data.lcmd_app_store_categories.store.appids, appid)])
+      error_message = "Every curated appid must exist in the app store."
+    }
+  }
+}
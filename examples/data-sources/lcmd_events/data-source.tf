@@ -0,0 +1,15 @@
+# Copyright (c) HashiCorp, Inc.
+
+data "lcmd_events" "recent_failures" {
+  since    = var.events_since
+  severity = "error"
+}
+
+output "recent_failure_count" {
+  value = length(data.lcmd_events.recent_failures.events)
+}
+
+variable "events_since" {
+  description = "RFC3339 timestamp to start collecting events from"
+  type        = string
+}
@@ -0,0 +1,9 @@
+# Copyright (c) HashiCorp, Inc.
+
+data "lcmd_file_stat" "backup" {
+  path = "/lzcapp/backups/photos/latest.tar.zst"
+}
+
+output "restore_needed" {
+  value = !data.lcmd_file_stat.backup.exists
+}
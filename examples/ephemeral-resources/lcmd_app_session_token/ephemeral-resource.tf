@@ -0,0 +1,11 @@
+# Copyright (c) HashiCorp, Inc.
+
+ephemeral "lcmd_app_session_token" "zitadel" {
+  appid = "cloud.lazycat.app.zitadel"
+  ttl   = "10m"
+}
+
+provider "zitadel" {
+  domain       = "zitadel.example.heiyu.space"
+  access_token = ephemeral.lcmd_app_session_token.zitadel.token
+}
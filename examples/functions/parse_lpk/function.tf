@@ -0,0 +1,14 @@
+# Copyright (c) HashiCorp, Inc.
+
+locals {
+  package = provider::lcmd::parse_lpk("${path.module}/dist/app.lpk")
+}
+
+resource "terraform_data" "check_package" {
+  lifecycle {
+    precondition {
+      condition     = local.package.appid == "cloud.lazycat.app.example"
+      error_message = "Unexpected appid ${local.package.appid} in package."
+    }
+  }
+}
@@ -0,0 +1,10 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "lcmd_file_download" "backup" {
+  path        = "/data/backups/photos.tar.gz"
+  destination = "${path.module}/backups/photos.tar.gz"
+}
+
+output "backup_sha256" {
+  value = lcmd_file_download.backup.sha256
+}
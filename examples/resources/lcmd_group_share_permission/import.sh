@@ -0,0 +1,6 @@
+#!/usr/bin/env bash
+# Copyright (c) HashiCorp, Inc.
+
+
+# Permissions are imported as "<share>:<group>", optionally prefixed with "<box>/".
+terraform import lcmd_group_share_permission.family_photos "photos:family"
@@ -0,0 +1,7 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "lcmd_group_share_permission" "family_photos" {
+  share      = "photos"
+  group      = "family"
+  permission = "write"
+}
@@ -0,0 +1,7 @@
+#!/usr/bin/env bash
+# Copyright (c) HashiCorp, Inc.
+
+
+# Published packages are imported by registry upload ID, optionally prefixed with "<box>/".
+# The next apply builds the configured source and reuses the upload when its SHA256 matches.
+terraform import lcmd_lpk_build.app "upload-id"
@@ -0,0 +1,8 @@
+#!/usr/bin/env bash
+# Copyright (c) HashiCorp, Inc.
+
+
+terraform import lcmd_maintenance_window.example "window-id"
+
+# Resources on a named box are imported as "<box>/<id>".
+terraform import lcmd_maintenance_window.example "staging/window-id"
@@ -0,0 +1,9 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "lcmd_maintenance_window" "weekend_nights" {
+  name     = "weekend nights"
+  days     = ["fri", "sat"]
+  start    = "23:00"
+  duration = "3h"
+  timezone = "Europe/Amsterdam"
+}
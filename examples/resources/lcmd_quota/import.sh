@@ -0,0 +1,8 @@
+#!/usr/bin/env bash
+# Copyright (c) HashiCorp, Inc.
+
+
+terraform import lcmd_quota.example "quota-id"
+
+# Resources on a named box are imported as "<box>/<id>".
+terraform import lcmd_quota.example "staging/quota-id"
@@ -0,0 +1,11 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "lcmd_quota" "photos" {
+  appid       = "cloud.lazycat.app.photos"
+  limit_bytes = 200 * 1024 * 1024 * 1024
+  enforcement = "soft"
+}
+
+output "photos_usage_bytes" {
+  value = lcmd_quota.photos.usage_bytes
+}
@@ -0,0 +1,11 @@
+# Copyright (c) HashiCorp, Inc.
+
+resource "lcmd_static_site" "docs" {
+  source_dir = "${path.module}/public"
+  appid      = "cloud.lazycat.app.docs"
+  subdomain  = "docs"
+}
+
+output "docs_domain" {
+  value = lcmd_static_site.docs.domain
+}
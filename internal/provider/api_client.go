@@ -5,37 +5,118 @@ package provider
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"hash"
 	"io"
 	"mime/multipart"
+	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
 var errNotFound = errors.New("resource not found")
 
 type apiAppInfo struct {
-	AppID    string `json:"appid"`
-	DeployID string `json:"deploy_id"`
-	LpkID    string `json:"lpk_id"`
-	Title    string `json:"title"`
-	Version  string `json:"version"`
-	Domain   string `json:"domain"`
-	Owner    string `json:"owner"`
+	AppID         string              `json:"appid"`
+	DeployID      string              `json:"deploy_id"`
+	LpkID         string              `json:"lpk_id"`
+	Title         string              `json:"title"`
+	Version       string              `json:"version"`
+	Domain        string              `json:"domain"`
+	Owner         string              `json:"owner"`
+	ClientLink    string              `json:"client_link,omitempty"`
+	Security      *apiSecurityOptions `json:"security,omitempty"`
+	StartPriority *int64              `json:"start_priority,omitempty"`
+	AutoStart     *bool               `json:"auto_start,omitempty"`
+	GPU           *bool               `json:"gpu,omitempty"`
+	Labels        map[string]string   `json:"labels,omitempty"`
+	DisplayTitle  string              `json:"display_title,omitempty"`
+	State         string              `json:"state,omitempty"`
+	Subdomain     string              `json:"subdomain,omitempty"`
+	CustomDomain  string              `json:"custom_domain,omitempty"`
+	Endpoint      string              `json:"endpoint,omitempty"`
+	Status        string              `json:"status,omitempty"`
+	InstalledAt   string              `json:"installed_at,omitempty"`
+	UnpackedSize  *int64              `json:"unpacked_size,omitempty"`
+	IconURL       string              `json:"icon_url,omitempty"`
+}
+
+type apiAutoUpgrade struct {
+	Enabled  bool   `json:"enabled"`
+	Channel  string `json:"channel,omitempty"`
+	Schedule string `json:"schedule,omitempty"`
+}
+
+// apiAppAccess is who can open an installed app. A nil field is left as it is
+// when setting access.
+type apiAppAccess struct {
+	Users  *[]string `json:"users,omitempty"`
+	Public *bool     `json:"public,omitempty"`
+}
+
+// apiAppResources are the CPU and memory limits of an installed app. Zero
+// means unlimited.
+type apiAppResources struct {
+	CPULimit         float64 `json:"cpu_limit"`
+	MemoryLimitBytes int64   `json:"memory_limit_bytes"`
+}
+
+type apiSecurityOptions struct {
+	ReadOnlyRootfs  bool     `json:"read_only_rootfs"`
+	NoNewPrivileges bool     `json:"no_new_privileges"`
+	CapDrop         []string `json:"cap_drop,omitempty"`
 }
 
 type apiInstallRequest struct {
-	UID       string `json:"uid"`
-	LPKURL    string `json:"lpk_url"`
-	Wait      bool   `json:"wait"`
-	Ephemeral bool   `json:"ephemeral"`
+	UID         string              `json:"uid"`
+	LPKURL      string              `json:"lpk_url"`
+	Wait        bool                `json:"wait"`
+	Ephemeral   bool                `json:"ephemeral"`
+	Security    *apiSecurityOptions `json:"security,omitempty"`
+	GPU         bool                `json:"gpu,omitempty"`
+	Staging     bool                `json:"staging,omitempty"`
+	AppIDSuffix string              `json:"appid_suffix,omitempty"`
+	StoreAppID  string              `json:"store_appid,omitempty"`
+	Channel     string              `json:"channel,omitempty"`
+	Labels      map[string]string   `json:"labels,omitempty"`
+}
+
+// apiUploadRequest describes a package upload. Overwrite is one of
+// overwritePolicies, or empty for the registry default.
+type apiUploadRequest struct {
+	UID       string
+	Name      string
+	Version   string
+	Overwrite string
+	Labels    map[string]string
+}
+
+// fields returns the multipart form fields of the upload.
+func (u apiUploadRequest) fields() ([]multipartField, error) {
+	fields := []multipartField{{"uid", u.UID}, {"name", u.Name}, {"version", u.Version}, {"overwrite", u.Overwrite}}
+	if len(u.Labels) > 0 {
+		labels, err := json.Marshal(u.Labels)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, multipartField{"labels", string(labels)})
+	}
+	return fields, nil
 }
 
 type apiUser struct {
@@ -60,13 +141,247 @@ type apiFileResponse struct {
 	ContentBase64 string `json:"content_base64"`
 }
 
+type apiFileStat struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Mode    string `json:"mode"`
+	ModTime string `json:"mtime"`
+	SHA256  string `json:"sha256"`
+	IsDir   bool   `json:"is_dir"`
+}
+
+// apiFileDownload describes a file written by FetchFileToPath.
+type apiFileDownload struct {
+	Size   int64
+	SHA256 string
+	// Resumed reports whether the transfer continued a partial download.
+	Resumed bool
+}
+
+// apiAppConfig is the environment and files pushed into the containers of
+// an installed app.
+type apiAppConfig struct {
+	Environment map[string]string  `json:"environment"`
+	Files       []apiAppConfigFile `json:"files"`
+	Restart     bool               `json:"restart"`
+}
+
+type apiAppConfigFile struct {
+	Path          string `json:"path"`
+	ContentBase64 string `json:"content_base64"`
+	Mode          string `json:"mode,omitempty"`
+}
+
+// apiAppUsage is a point-in-time resource usage sample of an app.
+type apiAppUsage struct {
+	CPUPercent       float64 `json:"cpu_percent"`
+	MemoryBytes      int64   `json:"memory_bytes"`
+	MemoryLimitBytes int64   `json:"memory_limit_bytes"`
+	NetworkRxBytes   int64   `json:"network_rx_bytes"`
+	NetworkTxBytes   int64   `json:"network_tx_bytes"`
+	SampledAt        string  `json:"sampled_at"`
+}
+
+// apiAppToken is a short-lived token scoped to a single app.
+type apiAppToken struct {
+	ID        string `json:"id"`
+	Token     string `json:"token"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// apiAppEndpoint is a port an installed app exposes through the box.
+type apiAppEndpoint struct {
+	Service  string `json:"service"`
+	Protocol string `json:"protocol"`
+	Port     int64  `json:"port"`
+	URL      string `json:"url,omitempty"`
+}
+
+// apiExecRequest runs a shell command in a container of an app. An empty
+// service is the main one.
+type apiExecRequest struct {
+	Command string `json:"command"`
+	Service string `json:"service,omitempty"`
+}
+
+// apiExecResult is the outcome of an apiExecRequest, with stdout and stderr
+// interleaved in Output.
+type apiExecResult struct {
+	ExitCode int    `json:"exit_code"`
+	Output   string `json:"output"`
+}
+
+// apiAppSnapshot is a backup of the data of an app taken by the NAS.
+type apiAppSnapshot struct {
+	ID        string `json:"id"`
+	CreatedAt string `json:"created_at,omitempty"`
+}
+
+// apiHealthProbe is the result of an HTTP request the NAS made to an app.
+type apiHealthProbe struct {
+	StatusCode int    `json:"status_code"`
+	Error      string `json:"error,omitempty"`
+}
+
+type apiEvent struct {
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	Type      string `json:"type"`
+	Severity  string `json:"severity"`
+	AppID     string `json:"appid"`
+	Message   string `json:"message"`
+}
+
+type apiEventFilter struct {
+	Since    string
+	Until    string
+	AppID    string
+	Severity string
+	Limit    int64
+}
+
+type apiQuota struct {
+	ID          string `json:"id,omitempty"`
+	UID         string `json:"uid,omitempty"`
+	AppID       string `json:"appid,omitempty"`
+	LimitBytes  int64  `json:"limit_bytes"`
+	Enforcement string `json:"enforcement"`
+	UsageBytes  int64  `json:"usage_bytes,omitempty"`
+}
+
+type apiGroupSharePermission struct {
+	Share      string `json:"share"`
+	Group      string `json:"group"`
+	Permission string `json:"permission"`
+}
+
+// apiPackageVersion is a published version of a package in the registry.
+type apiPackageVersion struct {
+	Version string `json:"version"`
+	LPKURL  string `json:"lpk_url"`
+}
+
+type apiStoreCategory struct {
+	ID     string   `json:"id"`
+	Name   string   `json:"name"`
+	Tags   []string `json:"tags"`
+	AppIDs []string `json:"appids"`
+}
+
+type apiMaintenanceWindow struct {
+	ID       string   `json:"id,omitempty"`
+	Name     string   `json:"name"`
+	Days     []string `json:"days,omitempty"`
+	Start    string   `json:"start"`
+	Duration string   `json:"duration"`
+	Timezone string   `json:"timezone,omitempty"`
+}
+
 type LcmdClient struct {
-	baseURL    *url.URL
-	httpClient *http.Client
-	User       string
+	baseURL      *url.URL
+	httpClient   *http.Client
+	uploadClient *http.Client
+	// externalClient fetches URLs off the endpoint, such as an lpk_url on
+	// another host. It honours the proxy but never the NAS dialer or CA.
+	externalClient *http.Client
+	// operationClient has no timeout of its own; it serves longRunning
+	// requests, which are bounded by their context deadline instead.
+	operationClient *http.Client
+	maxRetries      int
+	retryBackoff    time.Duration
+	limiter         *requestLimiter
+	breaker         *circuitBreaker
+	token           string
+	session         *clientSession
+	// boxes are the clients for the named endpoints of the provider `boxes`
+	// setting, shared by every client of one provider configuration.
+	boxes    map[string]*LcmdClient
+	publish  publishDefaults
+	labels   map[string]string
+	teardown *teardownScheduler
+	User     string
+	ReadOnly bool
+}
+
+// clientSession holds what the client learns from the NAS when it connects.
+// It is shared by every copy of the client made with ForUser.
+type clientSession struct {
+	server     apiVersionInfo
+	apiVersion int
+	features   []string
+	// windows are the maintenance windows registered on the NAS. When any
+	// exist, mutations are only allowed while one of them is open.
+	windows []apiMaintenanceWindow
+
+	// connect, when set, performs the configure-time checks on first use
+	// instead, so the provider can be configured without reaching the NAS.
+	mu        sync.Mutex
+	connect   func(ctx context.Context) error
+	connected bool
+	err       error
+}
+
+// connectingKey marks requests made by clientSession.connect itself.
+type connectingKey struct{}
+
+// setWindows replaces the cached maintenance windows with update applied to
+// a copy of them, so a window created, changed, or deleted during a run
+// gates the operations that follow it.
+func (s *clientSession) setWindows(update func([]apiMaintenanceWindow) []apiMaintenanceWindow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.windows = update(slices.Clone(s.windows))
+}
+
+// ready runs the deferred connect once and returns its result.
+func (c *LcmdClient) ready(ctx context.Context) error {
+	s := c.session
+	if s.connect == nil || ctx.Value(connectingKey{}) != nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.connected {
+		s.err = s.connect(context.WithValue(ctx, connectingKey{}, true))
+		s.connected = true
+	}
+	return s.err
 }
 
-func newAPIClient(endpoint string) (*LcmdClient, error) {
+// apiClientOptions carries the provider settings that shape the HTTP
+// transport used by LcmdClient.
+type apiClientOptions struct {
+	// TLSConfig, when set, replaces the default TLS configuration.
+	TLSConfig *tls.Config
+	// RequestTimeout bounds metadata calls; UploadTimeout bounds LPK uploads and
+	// streamed file downloads.
+	RequestTimeout time.Duration
+	UploadTimeout  time.Duration
+	// MaxRetries is the number of additional attempts made for idempotent
+	// requests that fail transiently, waiting RetryBackoff (doubling) between them.
+	MaxRetries   int
+	RetryBackoff time.Duration
+	// ProxyURL routes requests through an http, https, or socks5 proxy. When
+	// nil the standard HTTPS_PROXY/HTTP_PROXY/NO_PROXY variables apply.
+	ProxyURL *url.URL
+	// DialContext, when set, opens every connection to the NAS, e.g. through
+	// an SSH tunnel. Proxies are bypassed.
+	DialContext func(ctx context.Context, network, addr string) (net.Conn, error)
+	// DebugHTTP logs every request and response with secrets redacted.
+	DebugHTTP bool
+	// MaxConcurrentRequests and RequestsPerSecond throttle requests to the
+	// NAS; zero disables the corresponding limit.
+	MaxConcurrentRequests int
+	RequestsPerSecond     float64
+}
+
+const (
+	defaultRequestTimeout = 30 * time.Second
+	defaultUploadTimeout  = 10 * time.Minute
+	defaultRetryBackoff   = time.Second
+)
+
+func newAPIClient(endpoint string, opts apiClientOptions) (*LcmdClient, error) {
 	if endpoint == "" {
 		return nil, errors.New("endpoint is required")
 	}
@@ -74,21 +389,219 @@ func newAPIClient(endpoint string) (*LcmdClient, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid endpoint: %w", err)
 	}
+	proxy := http.ProxyFromEnvironment
+	if opts.ProxyURL != nil {
+		proxy = http.ProxyURL(opts.ProxyURL)
+	}
+	transport := &http.Transport{
+		Proxy:               proxy,
+		TLSClientConfig:     opts.TLSConfig,
+		MaxIdleConns:        10,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+	}
+	if opts.DialContext != nil {
+		transport.Proxy = nil
+		transport.DialContext = opts.DialContext
+	}
+	if parsed.Scheme == "unix" {
+		socketPath := parsed.Path
+		if socketPath == "" {
+			return nil, errors.New("unix endpoint must include a socket path")
+		}
+		if opts.DialContext != nil {
+			return nil, errors.New("unix endpoints cannot be combined with an ssh tunnel")
+		}
+		transport.Proxy = nil
+		transport.DialContext = func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var dialer net.Dialer
+			return dialer.DialContext(ctx, "unix", socketPath)
+		}
+		parsed = &url.URL{Scheme: "http", Host: unixSocketHost}
+	}
+	var roundTripper http.RoundTripper = transport
+	external := http.DefaultTransport.(*http.Transport).Clone()
+	external.Proxy = proxy
+	var externalRoundTripper http.RoundTripper = external
+	if opts.DebugHTTP {
+		roundTripper = &debugTransport{next: transport}
+		externalRoundTripper = &debugTransport{next: external}
+	}
+	if opts.RequestTimeout <= 0 {
+		opts.RequestTimeout = defaultRequestTimeout
+	}
+	if opts.UploadTimeout <= 0 {
+		opts.UploadTimeout = defaultUploadTimeout
+	}
+	if opts.RetryBackoff <= 0 {
+		opts.RetryBackoff = defaultRetryBackoff
+	}
 	return &LcmdClient{
 		baseURL: parsed,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   opts.RequestTimeout,
+			Transport: roundTripper,
+		},
+		uploadClient: &http.Client{
+			Timeout:   opts.UploadTimeout,
+			Transport: roundTripper,
+		},
+		externalClient: &http.Client{
+			Timeout:   opts.UploadTimeout,
+			Transport: externalRoundTripper,
 		},
+		operationClient: &http.Client{
+			Transport: roundTripper,
+		},
+		maxRetries:   opts.MaxRetries,
+		retryBackoff: opts.RetryBackoff,
+		limiter:      newRequestLimiter(opts.MaxConcurrentRequests, opts.RequestsPerSecond),
+		breaker:      &circuitBreaker{endpoint: endpoint},
+		session:      &clientSession{},
+		teardown:     newTeardownScheduler(),
 	}, nil
 }
 
-func (c *LcmdClient) InstallApp(ctx context.Context, lpkURL string, wait bool, ephemeral bool) (*apiAppInfo, error) {
+// unixSocketHost is the placeholder host used in request URLs when the API is
+// reached over a unix domain socket; the transport ignores it when dialing.
+const unixSocketHost = "lcmd.sock"
+
+// parseProxyURL validates proxyURL and attaches the optional credentials.
+func parseProxyURL(proxyURL, username, password string) (*url.URL, error) {
+	parsed, err := url.Parse(proxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy_url: %w", err)
+	}
+	switch parsed.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q, expected http, https, or socks5", parsed.Scheme)
+	}
+	if parsed.Host == "" {
+		return nil, errors.New("proxy_url must include a host")
+	}
+	if username != "" {
+		parsed.User = url.UserPassword(username, password)
+	}
+	return parsed, nil
+}
+
+// newTLSConfig builds the TLS configuration for the NAS API from an optional
+// CA bundle. It returns nil when the defaults apply.
+func newTLSConfig(caPEM []byte, insecure bool) (*tls.Config, error) {
+	if len(caPEM) == 0 && !insecure {
+		return nil, nil
+	}
+	cfg := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		// #nosec G402 -- only enabled through the explicit insecure provider flag.
+		InsecureSkipVerify: insecure,
+	}
+	if len(caPEM) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, errors.New("no valid certificates found in CA bundle")
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// longRunningKey marks contexts of requests that may legitimately take
+// longer than request_timeout, such as installs that wait for the app.
+type longRunningKey struct{}
+
+// longRunning returns ctx marked so its requests are bounded by the context
+// deadline, typically the resource timeouts, rather than request_timeout.
+func longRunning(ctx context.Context) context.Context {
+	return context.WithValue(ctx, longRunningKey{}, true)
+}
+
+func (c *LcmdClient) InstallApp(ctx context.Context, payload apiInstallRequest) (*apiAppInfo, error) {
+	if c.User == "" {
+		return nil, errors.New("user uid is not configured")
+	}
+	payload.UID = c.User
+	return c.installPackage(ctx, installRoute(payload), payload)
+}
+
+// installRoute is where payload is posted to install it: the app store for
+// store apps and the apps API for everything else.
+func installRoute(payload apiInstallRequest) string {
+	if payload.StoreAppID != "" {
+		return "/v1/store/install"
+	}
+	return "/v1/apps"
+}
+
+// errUpgradeUnsupported is returned by UpgradeApp when the NAS cannot
+// install a package over an existing app.
+var errUpgradeUnsupported = errors.New("the NAS does not support in-place upgrades")
+
+// featureAppUpgrade is the capability advertised by firmware that accepts
+// UpgradeApp.
+const featureAppUpgrade = "app_upgrade"
+
+// UpgradeApp installs the package described by payload over the installed
+// app appID, keeping its data and without stopping it for an uninstall.
+func (c *LcmdClient) UpgradeApp(ctx context.Context, appID string, payload apiInstallRequest) (*apiAppInfo, error) {
+	if c.User == "" {
+		return nil, errors.New("user uid is not configured")
+	}
+	if err := c.ready(ctx); err != nil {
+		return nil, err
+	}
+	if !c.supports(featureAppUpgrade) {
+		return nil, errUpgradeUnsupported
+	}
+	payload.UID = c.User
+	app, err := c.installPackage(ctx, path.Join("/v1/apps", appID, "upgrade"), payload)
+	if errors.Is(err, errNotFound) {
+		return nil, errUpgradeUnsupported
+	}
+	return app, err
+}
+
+// errBlueGreenUnsupported is returned by StageApp and PromoteApp when the NAS
+// cannot run two instances of an app side by side.
+var errBlueGreenUnsupported = errors.New("the NAS does not support blue/green deployments")
+
+// featureBlueGreen is the capability advertised by firmware that accepts
+// StageApp and PromoteApp.
+const featureBlueGreen = "blue_green"
+
+// StageApp installs the package described by payload as a new instance next
+// to an installed app of the same package, served under a staging subdomain
+// until PromoteApp moves the routes over.
+func (c *LcmdClient) StageApp(ctx context.Context, payload apiInstallRequest) (*apiAppInfo, error) {
 	if c.User == "" {
 		return nil, errors.New("user uid is not configured")
 	}
-	payload := &apiInstallRequest{UID: c.User, LPKURL: lpkURL, Wait: wait, Ephemeral: ephemeral}
+	if err := c.ready(ctx); err != nil {
+		return nil, err
+	}
+	if !c.supports(featureBlueGreen) {
+		return nil, errBlueGreenUnsupported
+	}
+	payload.UID = c.User
+	payload.Staging = true
+	return c.installPackage(ctx, installRoute(payload), payload)
+}
+
+// PromoteApp atomically moves the routes of replaces to the staged instance
+// appID and returns it as now served.
+func (c *LcmdClient) PromoteApp(ctx context.Context, appID, replaces string) (*apiAppInfo, error) {
+	params := map[string]string{"uid": c.User}
+	body := map[string]string{"replaces": replaces}
 	var app apiAppInfo
-	if err := c.do(ctx, http.MethodPost, "/v1/apps", nil, payload, &app); err != nil {
+	err := c.do(ctx, http.MethodPost, path.Join("/v1/apps", appID, "promote"), params, body, &app)
+	if errors.Is(err, errNotFound) {
+		return nil, errBlueGreenUnsupported
+	}
+	if err != nil {
 		return nil, err
 	}
 	return &app, nil
@@ -118,7 +631,269 @@ func (c *LcmdClient) DeleteApp(ctx context.Context, appID string, clearData bool
 		"uid":        c.User,
 		"clear_data": fmt.Sprintf("%t", clearData),
 	}
-	return c.do(ctx, http.MethodDelete, path.Join("/v1/apps", appID), params, nil, nil)
+	return c.do(longRunning(ctx), http.MethodDelete, path.Join("/v1/apps", appID), params, nil, nil)
+}
+
+// GetAppUsage samples the current CPU, memory, and network usage of an
+// installed app.
+func (c *LcmdClient) GetAppUsage(ctx context.Context, appID string) (*apiAppUsage, error) {
+	params := map[string]string{"uid": c.User}
+	var usage apiAppUsage
+	if err := c.do(ctx, http.MethodGet, path.Join("/v1/apps", appID, "usage"), params, nil, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
+// CreateAppToken mints a token for the API of an installed app that expires
+// after ttl.
+func (c *LcmdClient) CreateAppToken(ctx context.Context, appID string, ttl time.Duration) (*apiAppToken, error) {
+	params := map[string]string{"uid": c.User}
+	body := map[string]int64{"ttl_seconds": int64(ttl.Seconds())}
+	var token apiAppToken
+	err := c.do(ctx, http.MethodPost, path.Join("/v1/apps", appID, "tokens"), params, body, &token)
+	if errors.Is(err, errNotFound) {
+		return nil, fmt.Errorf("the NAS does not support app tokens, or %s is not installed", appID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// RevokeAppToken invalidates a token minted with CreateAppToken.
+func (c *LcmdClient) RevokeAppToken(ctx context.Context, appID, tokenID string) error {
+	params := map[string]string{"uid": c.User}
+	return c.do(ctx, http.MethodDelete, path.Join("/v1/apps", appID, "tokens", tokenID), params, nil, nil)
+}
+
+// errProbeUnsupported is returned by ProbeApp on firmware without health
+// probes.
+var errProbeUnsupported = errors.New("the NAS does not support application health probes")
+
+// ProbeApp has the NAS send an HTTP GET for path to port of the app's
+// container, or to its default HTTP port when port is zero.
+func (c *LcmdClient) ProbeApp(ctx context.Context, appID, probePath string, port int64) (*apiHealthProbe, error) {
+	params := map[string]string{"uid": c.User, "path": probePath}
+	if port > 0 {
+		params["port"] = strconv.FormatInt(port, 10)
+	}
+	var probe apiHealthProbe
+	err := c.do(ctx, http.MethodGet, path.Join("/v1/apps", appID, "health"), params, nil, &probe)
+	if errors.Is(err, errNotFound) {
+		return nil, errProbeUnsupported
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &probe, nil
+}
+
+// ListAppEndpoints returns the ports an installed app exposes.
+func (c *LcmdClient) ListAppEndpoints(ctx context.Context, appID string) ([]apiAppEndpoint, error) {
+	params := map[string]string{"uid": c.User}
+	var out struct {
+		Endpoints []apiAppEndpoint `json:"endpoints"`
+	}
+	if err := c.do(ctx, http.MethodGet, path.Join("/v1/apps", appID, "endpoints"), params, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Endpoints, nil
+}
+
+// ExecApp runs a command in a container of an installed app and waits for it
+// to exit.
+func (c *LcmdClient) ExecApp(ctx context.Context, appID string, exec apiExecRequest) (*apiExecResult, error) {
+	params := map[string]string{"uid": c.User}
+	var out apiExecResult
+	err := c.do(ctx, http.MethodPost, path.Join("/v1/apps", appID, "exec"), params, &exec, &out)
+	if errors.Is(err, errNotFound) {
+		return nil, errors.New("the NAS does not support running commands in applications")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SnapshotApp backs up the data of an installed app through the NAS backup
+// API and waits for the snapshot to complete.
+func (c *LcmdClient) SnapshotApp(ctx context.Context, appID string) (*apiAppSnapshot, error) {
+	params := map[string]string{"uid": c.User}
+	var out apiAppSnapshot
+	err := c.do(longRunning(ctx), http.MethodPost, path.Join("/v1/apps", appID, "snapshots"), params, nil, &out)
+	if errors.Is(err, errNotFound) {
+		return nil, errors.New("the NAS does not support application snapshots")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAppManifest returns the manifest of the package installed for appID, as
+// reported by the NAS in YAML or JSON.
+func (c *LcmdClient) GetAppManifest(ctx context.Context, appID string) ([]byte, error) {
+	params := map[string]string{"uid": c.User}
+	return c.doRaw(ctx, http.MethodGet, path.Join("/v1/apps", appID, "manifest"), params, nil)
+}
+
+// SetStartPriority sets the boot-time start order of an installed app. Lower
+// priorities start first.
+func (c *LcmdClient) SetStartPriority(ctx context.Context, appID string, priority int64) error {
+	params := map[string]string{"uid": c.User}
+	body := map[string]int64{"start_priority": priority}
+	err := c.do(ctx, http.MethodPut, path.Join("/v1/apps", appID, "start_priority"), params, body, nil)
+	if errors.Is(err, errNotFound) {
+		return errors.New("the NAS does not support start ordering")
+	}
+	return err
+}
+
+// SetAutoStart sets whether an installed app is started when the box boots.
+func (c *LcmdClient) SetAutoStart(ctx context.Context, appID string, autoStart bool) error {
+	params := map[string]string{"uid": c.User}
+	body := map[string]bool{"auto_start": autoStart}
+	err := c.do(ctx, http.MethodPut, path.Join("/v1/apps", appID, "auto_start"), params, body, nil)
+	if errors.Is(err, errNotFound) {
+		return errors.New("the NAS does not support disabling auto start")
+	}
+	return err
+}
+
+// SetDisplayTitle sets the name an installed app is shown under in the NAS UI.
+// An empty title restores the package title.
+func (c *LcmdClient) SetDisplayTitle(ctx context.Context, appID, title string) error {
+	params := map[string]string{"uid": c.User}
+	body := map[string]string{"display_title": title}
+	err := c.do(ctx, http.MethodPut, path.Join("/v1/apps", appID, "display_title"), params, body, nil)
+	if errors.Is(err, errNotFound) {
+		return errors.New("the NAS does not support display titles")
+	}
+	return err
+}
+
+// SetAppRoute binds an installed app to subdomain and, when not empty,
+// customDomain. An empty subdomain restores the one declared in the package.
+func (c *LcmdClient) SetAppRoute(ctx context.Context, appID, subdomain, customDomain string) (*apiAppInfo, error) {
+	params := map[string]string{"uid": c.User}
+	body := map[string]string{"subdomain": subdomain, "custom_domain": customDomain}
+	var app apiAppInfo
+	err := c.do(ctx, http.MethodPut, path.Join("/v1/apps", appID, "route"), params, body, &app)
+	if errors.Is(err, errNotFound) {
+		return nil, errors.New("the NAS does not support custom routing")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &app, nil
+}
+
+// SetAppState starts, stops, or pauses an installed app. state is one of
+// appStates.
+func (c *LcmdClient) SetAppState(ctx context.Context, appID, state string) error {
+	params := map[string]string{"uid": c.User}
+	body := map[string]string{"state": state}
+	err := c.do(ctx, http.MethodPut, path.Join("/v1/apps", appID, "state"), params, body, nil)
+	if errors.Is(err, errNotFound) {
+		return errors.New("the NAS does not support changing the application state")
+	}
+	return err
+}
+
+// GetAutoUpgrade returns the effective auto-upgrade policy of an installed
+// app.
+func (c *LcmdClient) GetAutoUpgrade(ctx context.Context, appID string) (*apiAutoUpgrade, error) {
+	params := map[string]string{"uid": c.User}
+	var out apiAutoUpgrade
+	if err := c.do(ctx, http.MethodGet, path.Join("/v1/apps", appID, "auto_upgrade"), params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetAutoUpgrade configures the NAS's built-in auto-update of an installed
+// app and returns the resulting policy.
+func (c *LcmdClient) SetAutoUpgrade(ctx context.Context, appID string, policy apiAutoUpgrade) (*apiAutoUpgrade, error) {
+	params := map[string]string{"uid": c.User}
+	var out apiAutoUpgrade
+	err := c.do(ctx, http.MethodPut, path.Join("/v1/apps", appID, "auto_upgrade"), params, &policy, &out)
+	if errors.Is(err, errNotFound) {
+		return nil, errors.New("the NAS does not support per-app auto-upgrade policies")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAppAccess returns which users can open an installed app and whether it
+// is reachable without signing in.
+func (c *LcmdClient) GetAppAccess(ctx context.Context, appID string) (*apiAppAccess, error) {
+	params := map[string]string{"uid": c.User}
+	var out apiAppAccess
+	if err := c.do(ctx, http.MethodGet, path.Join("/v1/apps", appID, "access"), params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetAppAccess changes who can open an installed app and returns the
+// resulting access.
+func (c *LcmdClient) SetAppAccess(ctx context.Context, appID string, access apiAppAccess) (*apiAppAccess, error) {
+	params := map[string]string{"uid": c.User}
+	var out apiAppAccess
+	err := c.do(ctx, http.MethodPut, path.Join("/v1/apps", appID, "access"), params, &access, &out)
+	if errors.Is(err, errNotFound) {
+		return nil, errors.New("the NAS does not support per-app access control")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// GetAppResources returns the CPU and memory limits of an installed app.
+func (c *LcmdClient) GetAppResources(ctx context.Context, appID string) (*apiAppResources, error) {
+	params := map[string]string{"uid": c.User}
+	var out apiAppResources
+	if err := c.do(ctx, http.MethodGet, path.Join("/v1/apps", appID, "resources"), params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetAppResources replaces the CPU and memory limits of an installed app and
+// returns the resulting ones.
+func (c *LcmdClient) SetAppResources(ctx context.Context, appID string, limits apiAppResources) (*apiAppResources, error) {
+	params := map[string]string{"uid": c.User}
+	var out apiAppResources
+	err := c.do(ctx, http.MethodPut, path.Join("/v1/apps", appID, "resources"), params, &limits, &out)
+	if errors.Is(err, errNotFound) {
+		return nil, errors.New("the NAS does not support resource limits")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// SetAppLabels replaces the labels of an installed app.
+func (c *LcmdClient) SetAppLabels(ctx context.Context, appID string, labels map[string]string) error {
+	params := map[string]string{"uid": c.User}
+	body := map[string]map[string]string{"labels": labels}
+	return c.do(ctx, http.MethodPut, path.Join("/v1/apps", appID, "labels"), params, body, nil)
+}
+
+// SetAppConfig replaces the environment variables and config files of an
+// installed app, restarting it when config.Restart is set.
+func (c *LcmdClient) SetAppConfig(ctx context.Context, appID string, config apiAppConfig) error {
+	params := map[string]string{"uid": c.User}
+	err := c.do(ctx, http.MethodPut, path.Join("/v1/apps", appID, "config"), params, config, nil)
+	if errors.Is(err, errNotFound) {
+		return errors.New("the NAS does not support app configuration")
+	}
+	return err
 }
 
 func (c *LcmdClient) ListUsers(ctx context.Context) ([]apiUser, error) {
@@ -149,7 +924,7 @@ func (c *LcmdClient) do(ctx context.Context, method string, p string, query map[
 	if out == nil || len(data) == 0 {
 		return nil
 	}
-	return json.Unmarshal(data, out)
+	return c.decodeResponse(data, out)
 }
 
 func (c *LcmdClient) buildURL(p string, query map[string]string) string {
@@ -167,87 +942,345 @@ func (c *LcmdClient) buildURL(p string, query map[string]string) string {
 	return u.String()
 }
 
+// authorize attaches the configured API token, if any, as a bearer token,
+// along with the negotiated API version.
+func (c *LcmdClient) authorize(req *http.Request) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.session.apiVersion != 0 {
+		req.Header.Set(apiVersionHeader, strconv.Itoa(c.session.apiVersion))
+	}
+}
+
 func (c *LcmdClient) doRaw(ctx context.Context, method string, p string, query map[string]string, body interface{}) ([]byte, error) {
+	if err := c.ready(ctx); err != nil {
+		return nil, err
+	}
 	endpoint := c.buildURL(p, query)
-	var bodyReader io.Reader
+	var payload []byte
 	if body != nil {
 		buf, err := json.Marshal(body)
 		if err != nil {
 			return nil, err
 		}
-		bodyReader = bytes.NewReader(buf)
+		payload = buf
+	}
+	// Non-idempotent requests are only retried when the NAS rejected them
+	// with a Retry-After, which means they were not processed.
+	attempts := 1 + c.maxRetries
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			delay := c.retryDelay(attempt)
+			if after, ok := retryAfter(lastErr); ok {
+				delay = max(delay, after)
+			}
+			if err := sleepContext(ctx, delay); err != nil {
+				return nil, err
+			}
+		}
+		data, retryable, err := c.doOnce(ctx, method, endpoint, p, payload)
+		if err == nil {
+			return data, nil
+		}
+		if _, ok := retryAfter(err); !retryable || (!isIdempotent(method) && !ok) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// doOnce performs a single request attempt. The boolean result reports
+// whether a failure is transient and the request may be retried.
+func (c *LcmdClient) doOnce(ctx context.Context, method, endpoint, p string, payload []byte) ([]byte, bool, error) {
+	var bodyReader io.Reader
+	if payload != nil {
+		bodyReader = bytes.NewReader(payload)
 	}
 	req, err := http.NewRequestWithContext(ctx, method, endpoint, bodyReader)
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	if body != nil {
+	if payload != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
-	resp, err := c.httpClient.Do(req)
+	c.authorize(req)
+	if err := c.breaker.wait(ctx); err != nil {
+		return nil, false, err
+	}
+	release, err := c.limiter.acquire(ctx)
 	if err != nil {
-		return nil, err
+		return nil, false, err
+	}
+	defer release()
+	httpClient := c.httpClient
+	if _, ok := ctx.Deadline(); ok && ctx.Value(longRunningKey{}) != nil {
+		httpClient = c.operationClient
 	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, false, err
+		}
+		c.breaker.record(err)
+		return nil, true, err
+	}
+	c.breaker.record(nil)
 	defer resp.Body.Close()
 	if resp.StatusCode == http.StatusNotFound {
-		return nil, errNotFound
+		return nil, false, errNotFound
 	}
 	if resp.StatusCode >= 300 {
 		msg, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("api %s %s: %s", method, p, strings.TrimSpace(string(msg)))
+		retryable := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		err := fmt.Errorf("api %s %s: %s", method, p, strings.TrimSpace(string(msg)))
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+			if after, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+				return nil, true, &retryAfterError{err: err, after: after}
+			}
+		}
+		return nil, retryable, err
 	}
 	data, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, err
+		return nil, true, err
+	}
+	return data, false, nil
+}
+
+// retryDelay returns the exponential backoff before the given retry attempt.
+func (c *LcmdClient) retryDelay(attempt int) time.Duration {
+	delay := c.retryBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+	}
+	return delay
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
 	}
-	return data, nil
 }
 
-func (c *LcmdClient) UploadLPK(ctx context.Context, uid, name, version, filePath string) (*apiUploadLPKResponse, error) {
-	if uid == "" {
+// UploadLPK uploads the package at filePath.
+func (c *LcmdClient) UploadLPK(ctx context.Context, upload apiUploadRequest, filePath string) (*apiUploadLPKResponse, error) {
+	if upload.UID == "" {
 		return nil, errors.New("uid is required for upload")
 	}
+	fields, err := upload.fields()
+	if err != nil {
+		return nil, err
+	}
 	f, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer f.Close()
-	body := &bytes.Buffer{}
-	writer := multipart.NewWriter(body)
-	if err := writer.WriteField("uid", uid); err != nil {
+	return c.postMultipart(ctx, "/v1/lpks", fields, "package", filepath.Base(filePath), f)
+}
+
+// errDeltaUnsupported is returned by UploadLPKDelta when the registry does
+// not accept delta uploads, signalling that a full upload is required.
+var errDeltaUnsupported = errors.New("registry does not support delta uploads")
+
+// featureDeltaUpload is the capability advertised by registries that accept
+// UploadLPKDelta.
+const featureDeltaUpload = "lpk_delta"
+
+// UploadLPKDelta uploads a binary delta against the previously uploaded
+// package baseID. The registry reconstructs the package and verifies it
+// matches targetSHA.
+func (c *LcmdClient) UploadLPKDelta(ctx context.Context, upload apiUploadRequest, baseID, baseSHA, targetSHA string, delta []byte) (*apiUploadLPKResponse, error) {
+	if upload.UID == "" {
+		return nil, errors.New("uid is required for upload")
+	}
+	if err := c.ready(ctx); err != nil {
+		return nil, err
+	}
+	if !c.supports(featureDeltaUpload) {
+		return nil, errDeltaUnsupported
+	}
+	fields, err := upload.fields()
+	if err != nil {
 		return nil, err
 	}
-	if name != "" {
-		_ = writer.WriteField("name", name)
+	fields = append(fields,
+		multipartField{"base_sha256", baseSHA},
+		multipartField{"target_sha256", targetSHA},
+		multipartField{"format", deltaFormat},
+	)
+	out, err := c.postMultipart(ctx, path.Join("/v1/lpks", baseID, "delta"), fields, "delta", "package.delta", bytes.NewReader(delta))
+	var statusErr *uploadStatusError
+	if errors.As(err, &statusErr) {
+		switch statusErr.status {
+		case http.StatusNotFound, http.StatusMethodNotAllowed, http.StatusUnsupportedMediaType, http.StatusNotImplemented:
+			return nil, errDeltaUnsupported
+		}
+	}
+	return out, err
+}
+
+// PackageSHA256 returns the hex-encoded SHA256 of the package at lpkURL. A
+// checksum the server advertises in reply to HEAD, as a Repr-Digest, Digest,
+// or X-Checksum-Sha256 header, is used when present; otherwise the package
+// is downloaded and hashed without being stored. The API token is only sent
+// when lpkURL is on the NAS endpoint.
+func (c *LcmdClient) PackageSHA256(ctx context.Context, lpkURL string) (string, error) {
+	target, err := url.Parse(lpkURL)
+	if err != nil {
+		return "", err
+	}
+	onEndpoint := target.Scheme == c.baseURL.Scheme && target.Host == c.baseURL.Host
+	request := func(method string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, lpkURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		httpClient := c.externalClient
+		if onEndpoint {
+			c.authorize(req)
+			httpClient = c.uploadClient
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode >= 300 {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%s %s: %s", method, lpkURL, resp.Status)
+		}
+		return resp, nil
+	}
+	if resp, err := request(http.MethodHead); err == nil {
+		resp.Body.Close()
+		if sum, ok := advertisedSHA256(resp.Header); ok {
+			return sum, nil
+		}
+	}
+	resp, err := request(http.MethodGet)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	digest := sha256.New()
+	if _, err := io.Copy(digest, resp.Body); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(digest.Sum(nil)), nil
+}
+
+// advertisedSHA256 extracts a SHA256 of the full content from response
+// headers, returning it hex-encoded.
+func advertisedSHA256(header http.Header) (string, bool) {
+	if sum := strings.TrimSpace(header.Get("X-Checksum-Sha256")); len(sum) == sha256.Size*2 {
+		if _, err := hex.DecodeString(sum); err == nil {
+			return strings.ToLower(sum), true
+		}
+	}
+	// Repr-Digest (RFC 9530) wraps the base64 value in colons; the older
+	// Digest header (RFC 3230) does not.
+	for _, name := range []string{"Repr-Digest", "Digest"} {
+		for _, entry := range strings.Split(header.Get(name), ",") {
+			algorithm, value, ok := strings.Cut(strings.TrimSpace(entry), "=")
+			if !ok || !strings.EqualFold(algorithm, "sha-256") {
+				continue
+			}
+			raw, err := base64.StdEncoding.DecodeString(strings.Trim(value, ":"))
+			if err == nil && len(raw) == sha256.Size {
+				return hex.EncodeToString(raw), true
+			}
+		}
+	}
+	return "", false
+}
+
+type multipartField struct {
+	name  string
+	value string
+}
+
+type uploadStatusError struct {
+	status int
+	msg    string
+}
+
+func (e *uploadStatusError) Error() string {
+	return fmt.Sprintf("upload failed: %s", e.msg)
+}
+
+func (c *LcmdClient) postMultipart(ctx context.Context, p string, fields []multipartField, fileField, fileName string, content io.Reader) (*apiUploadLPKResponse, error) {
+	if err := c.ready(ctx); err != nil {
+		return nil, err
 	}
-	if version != "" {
-		_ = writer.WriteField("version", version)
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		if err := writer.WriteField(field.name, field.value); err != nil {
+			return nil, err
+		}
 	}
-	part, err := writer.CreateFormFile("package", filepath.Base(filePath))
+	part, err := writer.CreateFormFile(fileField, fileName)
 	if err != nil {
 		return nil, err
 	}
-	if _, err := io.Copy(part, f); err != nil {
+	if _, err := io.Copy(part, content); err != nil {
 		return nil, err
 	}
 	writer.Close()
-	endpoint := c.buildURL("/v1/lpks", nil)
+	endpoint := c.buildURL(p, nil)
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, body)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Content-Type", writer.FormDataContentType())
-	resp, err := c.httpClient.Do(req)
+	c.authorize(req)
+	if err := c.breaker.wait(ctx); err != nil {
+		return nil, err
+	}
+	release, err := c.limiter.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	resp, err := c.uploadClient.Do(req)
 	if err != nil {
+		if ctx.Err() == nil {
+			c.breaker.record(err)
+		}
 		return nil, err
 	}
+	c.breaker.record(nil)
 	defer resp.Body.Close()
 	if resp.StatusCode >= 300 {
 		msg, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("upload failed: %s", strings.TrimSpace(string(msg)))
+		return nil, &uploadStatusError{status: resp.StatusCode, msg: strings.TrimSpace(string(msg))}
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
 	}
 	var out apiUploadLPKResponse
-	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+	if err := c.decodeResponse(data, &out); err != nil {
 		return nil, err
 	}
 	return &out, nil
@@ -270,6 +1303,171 @@ func (c *LcmdClient) FetchFile(ctx context.Context, path string) (*apiFileRespon
 	return &out, nil
 }
 
+// StatFile returns metadata for path without transferring its contents. It
+// returns errNotFound when the path does not exist.
+func (c *LcmdClient) StatFile(ctx context.Context, path string) (*apiFileStat, error) {
+	if path == "" {
+		return nil, errors.New("path is required")
+	}
+	params := map[string]string{
+		"path": path,
+	}
+	if c.User != "" {
+		params["uid"] = c.User
+	}
+	var out apiFileStat
+	if err := c.do(ctx, http.MethodGet, "/v1/files/stat", params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// FetchFileToPath streams remotePath to localPath without holding it in
+// memory. Data is written to localPath with a ".part" suffix first, so an
+// interrupted transfer is resumed with a Range request on the next call; the
+// file is only moved into place once its SHA256 matches the checksum the NAS
+// reports. A resumed transfer that fails verification is restarted once from
+// the beginning, in case the remote file changed in between.
+func (c *LcmdClient) FetchFileToPath(ctx context.Context, remotePath, localPath string) (*apiFileDownload, error) {
+	if localPath == "" {
+		return nil, errors.New("local path is required")
+	}
+	stat, err := c.StatFile(ctx, remotePath)
+	if err != nil {
+		return nil, err
+	}
+	if stat.IsDir {
+		return nil, fmt.Errorf("%s is a directory", remotePath)
+	}
+	if err := os.MkdirAll(filepath.Dir(localPath), 0o755); err != nil {
+		return nil, err
+	}
+	partial := localPath + ".part"
+	out, err := c.fetchPartial(ctx, remotePath, partial, stat)
+	if errors.Is(err, errChecksumMismatch) && out != nil && out.Resumed {
+		if err := os.Remove(partial); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		out, err = c.fetchPartial(ctx, remotePath, partial, stat)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Rename(partial, localPath); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+var errChecksumMismatch = errors.New("checksum mismatch")
+
+// fetchPartial completes the partial download at partial, which may not
+// exist yet, and verifies it against stat.
+func (c *LcmdClient) fetchPartial(ctx context.Context, remotePath, partial string, stat *apiFileStat) (*apiFileDownload, error) {
+	f, err := os.OpenFile(partial, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	digest := sha256.New()
+	offset, err := io.Copy(digest, f)
+	if err != nil {
+		return nil, err
+	}
+	if offset > stat.Size {
+		if err := restartPartial(f); err != nil {
+			return nil, err
+		}
+		digest.Reset()
+		offset = 0
+	}
+	out := &apiFileDownload{Resumed: offset > 0}
+	if offset < stat.Size || stat.Size == 0 {
+		written, restarted, err := c.streamFile(ctx, remotePath, offset, f, digest)
+		if err != nil {
+			return out, err
+		}
+		if restarted {
+			out.Resumed = false
+			offset = 0
+		}
+		offset += written
+	}
+	if err := f.Sync(); err != nil {
+		return out, err
+	}
+	out.Size = offset
+	out.SHA256 = hex.EncodeToString(digest.Sum(nil))
+	if stat.SHA256 != "" && !strings.EqualFold(out.SHA256, stat.SHA256) {
+		return out, fmt.Errorf("%w: %s has sha256 %s, downloaded %s", errChecksumMismatch, remotePath, stat.SHA256, out.SHA256)
+	}
+	return out, nil
+}
+
+// streamFile copies the remote file from offset onwards to f, which is
+// positioned at offset, feeding what it writes to digest. When the NAS ignores
+// the Range request and sends the whole file, f and digest are reset and
+// restarted is true.
+func (c *LcmdClient) streamFile(ctx context.Context, remotePath string, offset int64, f *os.File, digest hash.Hash) (int64, bool, error) {
+	if err := c.ready(ctx); err != nil {
+		return 0, false, err
+	}
+	params := map[string]string{"path": remotePath}
+	if c.User != "" {
+		params["uid"] = c.User
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.buildURL("/v1/files/content", params), nil)
+	if err != nil {
+		return 0, false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	c.authorize(req)
+	if err := c.breaker.wait(ctx); err != nil {
+		return 0, false, err
+	}
+	release, err := c.limiter.acquire(ctx)
+	if err != nil {
+		return 0, false, err
+	}
+	defer release()
+	resp, err := c.uploadClient.Do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.breaker.record(err)
+		}
+		return 0, false, err
+	}
+	c.breaker.record(nil)
+	defer resp.Body.Close()
+	restarted := false
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return 0, false, errNotFound
+	case resp.StatusCode == http.StatusOK && offset > 0:
+		if err := restartPartial(f); err != nil {
+			return 0, false, err
+		}
+		digest.Reset()
+		restarted = true
+	case resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusPartialContent:
+		msg, _ := io.ReadAll(resp.Body)
+		return 0, false, fmt.Errorf("api GET /v1/files/content: %s", strings.TrimSpace(string(msg)))
+	}
+	written, err := io.Copy(io.MultiWriter(f, digest), resp.Body)
+	return written, restarted, err
+}
+
+// restartPartial empties a partial download so it is written from the start.
+func restartPartial(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.Seek(0, io.SeekStart)
+	return err
+}
+
 func (c *LcmdClient) DeleteLPK(ctx context.Context, id string) error {
 	if c.User == "" {
 		return errors.New("user uid is not configured")
@@ -277,3 +1475,152 @@ func (c *LcmdClient) DeleteLPK(ctx context.Context, id string) error {
 	params := map[string]string{"uid": c.User}
 	return c.do(ctx, http.MethodDelete, path.Join("/v1/lpks", id), params, nil, nil)
 }
+
+// GetLPK returns the registry record of the upload id.
+func (c *LcmdClient) GetLPK(ctx context.Context, id string) (*apiUploadLPKResponse, error) {
+	if c.User == "" {
+		return nil, errors.New("user uid is not configured")
+	}
+	params := map[string]string{"uid": c.User}
+	var out apiUploadLPKResponse
+	if err := c.do(ctx, http.MethodGet, path.Join("/v1/lpks", id), params, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *LcmdClient) ListEvents(ctx context.Context, filter apiEventFilter) ([]apiEvent, error) {
+	params := map[string]string{}
+	if c.User != "" {
+		params["uid"] = c.User
+	}
+	if filter.Since != "" {
+		params["since"] = filter.Since
+	}
+	if filter.Until != "" {
+		params["until"] = filter.Until
+	}
+	if filter.AppID != "" {
+		params["appid"] = filter.AppID
+	}
+	if filter.Severity != "" {
+		params["severity"] = filter.Severity
+	}
+	if filter.Limit > 0 {
+		params["limit"] = fmt.Sprintf("%d", filter.Limit)
+	}
+	var out []apiEvent
+	if err := c.do(ctx, http.MethodGet, "/v1/events", params, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *LcmdClient) SetQuota(ctx context.Context, quota apiQuota) (*apiQuota, error) {
+	var out apiQuota
+	if err := c.do(ctx, http.MethodPut, "/v1/quotas", nil, &quota, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *LcmdClient) GetQuota(ctx context.Context, id string) (*apiQuota, error) {
+	var out apiQuota
+	if err := c.do(ctx, http.MethodGet, path.Join("/v1/quotas", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *LcmdClient) DeleteQuota(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, path.Join("/v1/quotas", id), nil, nil, nil)
+}
+
+// SetGroupSharePermission grants a group a permission level on a share,
+// replacing any level it already had.
+func (c *LcmdClient) SetGroupSharePermission(ctx context.Context, grant apiGroupSharePermission) (*apiGroupSharePermission, error) {
+	var out apiGroupSharePermission
+	body := map[string]string{"permission": grant.Permission}
+	if err := c.do(ctx, http.MethodPut, path.Join("/v1/shares", grant.Share, "groups", grant.Group), nil, body, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *LcmdClient) GetGroupSharePermission(ctx context.Context, share, group string) (*apiGroupSharePermission, error) {
+	var out apiGroupSharePermission
+	if err := c.do(ctx, http.MethodGet, path.Join("/v1/shares", share, "groups", group), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *LcmdClient) DeleteGroupSharePermission(ctx context.Context, share, group string) error {
+	return c.do(ctx, http.MethodDelete, path.Join("/v1/shares", share, "groups", group), nil, nil, nil)
+}
+
+// ListPackageVersions returns the versions of the package appID published in
+// the registry, in no particular order.
+func (c *LcmdClient) ListPackageVersions(ctx context.Context, appID string) ([]apiPackageVersion, error) {
+	var out struct {
+		Versions []apiPackageVersion `json:"versions"`
+	}
+	if err := c.do(ctx, http.MethodGet, path.Join("/v1/registry/packages", appID, "versions"), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out.Versions, nil
+}
+
+func (c *LcmdClient) ListStoreCategories(ctx context.Context) ([]apiStoreCategory, error) {
+	var out []apiStoreCategory
+	if err := c.do(ctx, http.MethodGet, "/v1/store/categories", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *LcmdClient) ListMaintenanceWindows(ctx context.Context) ([]apiMaintenanceWindow, error) {
+	var out []apiMaintenanceWindow
+	if err := c.do(ctx, http.MethodGet, "/v1/maintenance_windows", nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *LcmdClient) SetMaintenanceWindow(ctx context.Context, window apiMaintenanceWindow) (*apiMaintenanceWindow, error) {
+	var out apiMaintenanceWindow
+	if err := c.do(ctx, http.MethodPut, "/v1/maintenance_windows", nil, &window, &out); err != nil {
+		return nil, err
+	}
+	stored := out
+	if stored.ID == "" {
+		stored = window
+	}
+	c.session.setWindows(func(windows []apiMaintenanceWindow) []apiMaintenanceWindow {
+		i := slices.IndexFunc(windows, func(w apiMaintenanceWindow) bool { return w.ID == stored.ID })
+		if i < 0 {
+			return append(windows, stored)
+		}
+		windows[i] = stored
+		return windows
+	})
+	return &out, nil
+}
+
+func (c *LcmdClient) GetMaintenanceWindow(ctx context.Context, id string) (*apiMaintenanceWindow, error) {
+	var out apiMaintenanceWindow
+	if err := c.do(ctx, http.MethodGet, path.Join("/v1/maintenance_windows", id), nil, nil, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+func (c *LcmdClient) DeleteMaintenanceWindow(ctx context.Context, id string) error {
+	err := c.do(ctx, http.MethodDelete, path.Join("/v1/maintenance_windows", id), nil, nil, nil)
+	if err == nil || errors.Is(err, errNotFound) {
+		c.session.setWindows(func(windows []apiMaintenanceWindow) []apiMaintenanceWindow {
+			return slices.DeleteFunc(windows, func(w apiMaintenanceWindow) bool { return w.ID == id })
+		})
+	}
+	return err
+}
@@ -0,0 +1,157 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// The provider speaks apiVersionCurrent natively. Older firmware generations
+// are supported by rewriting their responses into the current shape before
+// decoding, so the api* types only ever describe the current schema.
+const (
+	apiVersionLegacy  = 1
+	apiVersionCurrent = 2
+)
+
+// apiVersionHeader is sent on every request once a version is negotiated so
+// firmware that supports several response schemas picks the matching one.
+const apiVersionHeader = "X-Lcmd-Api-Version"
+
+type apiVersionInfo struct {
+	Firmware      string `json:"firmware_version"`
+	APIVersion    int    `json:"api_version"`
+	MinAPIVersion int    `json:"min_api_version"`
+}
+
+type apiCapabilities struct {
+	APIVersion    int      `json:"api_version"`
+	MinAPIVersion int      `json:"min_api_version"`
+	Features      []string `json:"features"`
+}
+
+// responseShims maps a negotiated API version to the field renames needed to
+// turn its responses into the current schema.
+var responseShims = map[int]map[string]string{
+	apiVersionLegacy: {
+		"appId":         "appid",
+		"deployId":      "deploy_id",
+		"lpkId":         "lpk_id",
+		"clientLink":    "client_link",
+		"startPriority": "start_priority",
+		"downloadUrl":   "download_url",
+		"contentBase64": "content_base64",
+		"limitBytes":    "limit_bytes",
+		"usageBytes":    "usage_bytes",
+		"appIds":        "appids",
+	},
+}
+
+// NegotiateVersion reads the firmware and API version from /v1/version and the
+// optional feature list from /v1/capabilities, then picks the newest response
+// schema both sides understand. Firmware without either endpoint predates
+// versioning and is treated as apiVersionLegacy.
+func (c *LcmdClient) NegotiateVersion(ctx context.Context) error {
+	var info apiVersionInfo
+	err := c.do(ctx, http.MethodGet, "/v1/version", nil, nil, &info)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return err
+	}
+	var caps apiCapabilities
+	capsErr := c.do(ctx, http.MethodGet, "/v1/capabilities", nil, nil, &caps)
+	if capsErr != nil && !errors.Is(capsErr, errNotFound) {
+		return capsErr
+	}
+	if info.APIVersion == 0 {
+		info.APIVersion = caps.APIVersion
+	}
+	if info.MinAPIVersion == 0 {
+		info.MinAPIVersion = caps.MinAPIVersion
+	}
+	info.APIVersion = max(info.APIVersion, apiVersionLegacy)
+	if info.MinAPIVersion > apiVersionCurrent {
+		return errors.New("the NAS requires API version " + strconv.Itoa(info.MinAPIVersion) + ", which is newer than this provider supports; upgrade the provider")
+	}
+	c.session.server = info
+	c.session.apiVersion = min(info.APIVersion, apiVersionCurrent)
+	if capsErr == nil {
+		c.session.features = caps.Features
+	}
+	return nil
+}
+
+// supports reports whether the NAS advertises feature. Firmware that does not
+// publish a feature list is assumed to support everything, and callers fall
+// back when a request is rejected.
+func (c *LcmdClient) supports(feature string) bool {
+	if c.session.features == nil {
+		return true
+	}
+	return slices.Contains(c.session.features, feature)
+}
+
+// advertises reports whether the NAS explicitly lists feature, for features
+// that cannot be tried and fallen back from because older firmware would
+// silently misread the request.
+func (c *LcmdClient) advertises(feature string) bool {
+	return slices.Contains(c.session.features, feature)
+}
+
+// describeServer renders the NAS version for diagnostics.
+func (c *LcmdClient) describeServer() string {
+	firmware := c.session.server.Firmware
+	if firmware == "" {
+		firmware = "unknown"
+	}
+	return fmt.Sprintf("firmware %s, API version %d", firmware, c.session.server.APIVersion)
+}
+
+// decodeResponse unmarshals data into out after applying the compatibility
+// shims for the negotiated API version.
+func (c *LcmdClient) decodeResponse(data []byte, out any) error {
+	renames, ok := responseShims[c.session.apiVersion]
+	if !ok {
+		return json.Unmarshal(data, out)
+	}
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	normalized, err := json.Marshal(renameKeys(raw, renames))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(normalized, out)
+}
+
+// renameKeys rewrites object keys throughout v. Keys already in the current
+// form win over their legacy spelling.
+func renameKeys(v any, renames map[string]string) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, child := range val {
+			if renamed, ok := renames[k]; ok {
+				if _, exists := val[renamed]; exists {
+					continue
+				}
+				k = renamed
+			}
+			out[k] = renameKeys(child, renames)
+		}
+		return out
+	case []any:
+		for i, child := range val {
+			val[i] = renameKeys(child, renames)
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/setvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+func visibleToUsersAttribute() schema.SetAttribute {
+	return schema.SetAttribute{
+		MarkdownDescription: "UIDs of the NAS users that can open the application. Changes made in the NAS UI show up as drift. Removing the attribute leaves the current access in place",
+		ElementType:         types.StringType,
+		Optional:            true,
+		Validators: []validator.Set{
+			setvalidator.ValueStringsAre(stringvalidator.LengthAtLeast(1)),
+		},
+	}
+}
+
+func publicAccessAttribute() schema.BoolAttribute {
+	return schema.BoolAttribute{
+		MarkdownDescription: "Whether the application can be opened without signing in to the NAS, e.g. to share a website. Changes made in the NAS UI show up as drift. Removing the attribute leaves the current setting in place",
+		Optional:            true,
+	}
+}
+
+// hasAppAccess reports whether data manages any access setting.
+func hasAppAccess(data *LpkResourceModel) bool {
+	return !data.VisibleTo.IsNull() || !data.PublicAccess.IsNull()
+}
+
+// sameAppAccess reports whether plan asks for the access already in state.
+// Settings plan leaves unmanaged always match.
+func sameAppAccess(plan, state *LpkResourceModel) bool {
+	return (plan.VisibleTo.IsNull() || plan.VisibleTo.Equal(state.VisibleTo)) &&
+		(plan.PublicAccess.IsNull() || plan.PublicAccess.Equal(state.PublicAccess))
+}
+
+// setAppAccess applies the managed access settings of data and records the
+// resulting ones. Unmanaged settings are left as they are on the NAS.
+func setAppAccess(ctx context.Context, client Client, data *LpkResourceModel) error {
+	var request apiAppAccess
+	if !data.VisibleTo.IsNull() {
+		users := []string{}
+		if diags := data.VisibleTo.ElementsAs(ctx, &users, false); diags.HasError() {
+			return errors.New("convert visible_to_users")
+		}
+		request.Users = &users
+	}
+	if !data.PublicAccess.IsNull() {
+		public := data.PublicAccess.ValueBool()
+		request.Public = &public
+	}
+	access, err := client.SetAppAccess(ctx, data.Appid.ValueString(), request)
+	if err != nil {
+		return err
+	}
+	applyAppAccess(data, access)
+	return nil
+}
+
+// applyAppAccess records access in the managed attributes of data.
+func applyAppAccess(data *LpkResourceModel, access *apiAppAccess) {
+	if !data.VisibleTo.IsNull() && access.Users != nil {
+		users := make([]attr.Value, 0, len(*access.Users))
+		for _, uid := range *access.Users {
+			users = append(users, types.StringValue(uid))
+		}
+		data.VisibleTo = types.SetValueMust(types.StringType, users)
+	}
+	if !data.PublicAccess.IsNull() && access.Public != nil {
+		data.PublicAccess = types.BoolValue(*access.Public)
+	}
+}
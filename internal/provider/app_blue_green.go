@@ -0,0 +1,43 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// blueGreenDeploy installs install next to the running app oldID under a
+// staging subdomain, checks its health and bakes the canary, moves the
+// routes of oldID over to it, and uninstalls oldID. Until the routes are
+// swapped oldID keeps serving and a failure removes the staged instance
+// again, so an error leaves the application as it was. Failing to uninstall
+// oldID afterwards is only a warning, as the new version is already serving.
+func blueGreenDeploy(ctx context.Context, client Client, oldID string, install apiInstallRequest, check *AppHealthCheckModel, canary *AppCanaryModel, diags *diag.Diagnostics) (*apiAppInfo, error) {
+	staged, err := client.StageApp(ctx, install)
+	if err != nil {
+		return nil, err
+	}
+	discard := func(cause error) error {
+		if err := client.DeleteApp(ctx, staged.AppID, false); err != nil {
+			return fmt.Errorf("%w; removing the staged instance %s also failed: %s", cause, staged.AppID, err)
+		}
+		return fmt.Errorf("%w. The staged instance was removed and %s keeps serving", cause, oldID)
+	}
+	if err := waitHealthy(ctx, client, staged.AppID, check); err != nil {
+		return nil, discard(err)
+	}
+	if err := bakeCanary(ctx, client, staged.AppID, check, canary); err != nil {
+		return nil, discard(err)
+	}
+	promoted, err := client.PromoteApp(ctx, staged.AppID, oldID)
+	if err != nil {
+		return nil, discard(fmt.Errorf("swap routes: %w", err))
+	}
+	if err := client.DeleteApp(ctx, oldID, false); err != nil {
+		diags.AddWarning("Previous instance not removed", fmt.Sprintf("The new version serves the application, but uninstalling the previous instance %s failed and it has to be removed by hand: %s", oldID, err))
+	}
+	return promoted, nil
+}
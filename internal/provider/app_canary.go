@@ -0,0 +1,103 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// AppCanaryModel describes how long an upgraded app is watched before the
+// upgrade counts as done.
+type AppCanaryModel struct {
+	BakeDuration     types.String `tfsdk:"bake_duration"`
+	Interval         types.String `tfsdk:"interval"`
+	FailureThreshold types.Int64  `tfsdk:"failure_threshold"`
+}
+
+const (
+	defaultCanaryInterval  = 30 * time.Second
+	defaultCanaryThreshold = 1
+)
+
+func canaryBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		MarkdownDescription: "Watch an upgraded application for a while before the upgrade counts as done. After `health_check` passes, its probe keeps running for `bake_duration`; if it fails, the previous `lpk_url` is reinstalled regardless of `rollback_on_failure` and the apply fails. The bake counts toward the update timeout",
+		Attributes: map[string]schema.Attribute{
+			"bake_duration": schema.StringAttribute{
+				MarkdownDescription: "How long the new version is watched as a Go duration, e.g. `10m`",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(durationPattern, "must be a Go duration such as 30s or 1h30m"),
+				},
+			},
+			"interval": schema.StringAttribute{
+				MarkdownDescription: "Delay between probes as a Go duration. Defaults to `30s`",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(durationPattern, "must be a Go duration such as 30s or 1h30m"),
+				},
+			},
+			"failure_threshold": schema.Int64Attribute{
+				MarkdownDescription: "Consecutive failed probes that fail the canary. Defaults to `1`",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+		},
+	}
+}
+
+// bakeCanary probes appID with the probe of check until canary's bake
+// duration is over, and fails once the probe failed failure_threshold times
+// in a row. A nil canary passes immediately.
+func bakeCanary(ctx context.Context, client Client, appID string, check *AppHealthCheckModel, canary *AppCanaryModel) error {
+	if canary == nil {
+		return nil
+	}
+	probe := probeFor(check)
+	interval := timeoutOr(canary.Interval, defaultCanaryInterval)
+	threshold := canary.FailureThreshold.ValueInt64()
+	if canary.FailureThreshold.IsNull() {
+		threshold = defaultCanaryThreshold
+	}
+	bake := timeoutOr(canary.BakeDuration, 0)
+	deadline := time.Now().Add(bake)
+	tflog.Info(ctx, "baking canary", map[string]any{"appid": appID, "bake_duration": bake.String()})
+	var failures int64
+	for {
+		healthy, last, err := probe.run(ctx, client, appID)
+		if err != nil {
+			return err
+		}
+		if healthy {
+			failures = 0
+		} else {
+			failures++
+			tflog.Debug(ctx, "canary probe failed", map[string]any{
+				"appid":    appID,
+				"failures": failures,
+				"last":     last,
+			})
+			if failures >= threshold {
+				return fmt.Errorf("%s failed %d consecutive probes of %s during its canary bake; last result: %s", appID, failures, probe.path, last)
+			}
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil
+		}
+		if err := sleepContext(ctx, min(interval, remaining)); err != nil {
+			return fmt.Errorf("the canary bake of %s did not finish before the update timed out", appID)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"regexp"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// AppConfigFileModel is a file written into the containers of the app.
+type AppConfigFileModel struct {
+	Path    types.String `tfsdk:"path"`
+	Content types.String `tfsdk:"content"`
+	Mode    types.String `tfsdk:"mode"`
+}
+
+var (
+	configFilePathPattern = regexp.MustCompile(`^/`)
+	fileModePattern       = regexp.MustCompile(`^0?[0-7]{3}$`)
+)
+
+func environmentAttribute() schema.MapAttribute {
+	return schema.MapAttribute{
+		MarkdownDescription: "Environment variables set in the containers of the application after install. Changing them restarts the application. Changes made on the NAS are not detected",
+		ElementType:         types.StringType,
+		Optional:            true,
+	}
+}
+
+func configFilesBlock() schema.ListNestedBlock {
+	return schema.ListNestedBlock{
+		MarkdownDescription: "Files written into the containers of the application after install. Changing them restarts the application. Changes made on the NAS are not detected",
+		NestedObject: schema.NestedBlockObject{
+			Attributes: map[string]schema.Attribute{
+				"path": schema.StringAttribute{
+					MarkdownDescription: "Absolute path of the file inside the container, e.g. `/lzcapp/var/config.yml`",
+					Required:            true,
+					Validators: []validator.String{
+						stringvalidator.RegexMatches(configFilePathPattern, "must be an absolute path"),
+					},
+				},
+				"content": schema.StringAttribute{
+					MarkdownDescription: "Contents of the file",
+					Required:            true,
+					Sensitive:           true,
+				},
+				"mode": schema.StringAttribute{
+					MarkdownDescription: "Permission bits in octal, e.g. `0600`. Defaults to `0644`",
+					Optional:            true,
+					Validators: []validator.String{
+						stringvalidator.RegexMatches(fileModePattern, "must be octal permission bits such as 0644"),
+					},
+				},
+			},
+		},
+	}
+}
+
+// hasAppConfig reports whether data configures environment or files.
+func hasAppConfig(data *LpkResourceModel) bool {
+	return len(data.Environment.Elements()) > 0 || len(data.ConfigFiles) > 0
+}
+
+// sameAppConfig reports whether plan and state push the same configuration.
+func sameAppConfig(plan, state *LpkResourceModel) bool {
+	if !plan.Environment.Equal(state.Environment) || len(plan.ConfigFiles) != len(state.ConfigFiles) {
+		return false
+	}
+	for i := range plan.ConfigFiles {
+		a, b := plan.ConfigFiles[i], state.ConfigFiles[i]
+		if !a.Path.Equal(b.Path) || !a.Content.Equal(b.Content) || !a.Mode.Equal(b.Mode) {
+			return false
+		}
+	}
+	return true
+}
+
+// appConfigRequest builds the configuration pushed for data. An empty one
+// clears what an earlier apply pushed.
+func appConfigRequest(ctx context.Context, data *LpkResourceModel) (apiAppConfig, diag.Diagnostics) {
+	config := apiAppConfig{
+		Environment: map[string]string{},
+		Files:       []apiAppConfigFile{},
+		Restart:     true,
+	}
+	var diags diag.Diagnostics
+	if !data.Environment.IsNull() && !data.Environment.IsUnknown() {
+		diags.Append(data.Environment.ElementsAs(ctx, &config.Environment, false)...)
+	}
+	for _, file := range data.ConfigFiles {
+		config.Files = append(config.Files, apiAppConfigFile{
+			Path:          file.Path.ValueString(),
+			ContentBase64: base64.StdEncoding.EncodeToString([]byte(file.Content.ValueString())),
+			Mode:          file.Mode.ValueString(),
+		})
+	}
+	return config, diags
+}
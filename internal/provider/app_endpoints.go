@@ -0,0 +1,91 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// AppEndpointModel is a port an installed app exposes.
+type AppEndpointModel struct {
+	Service  types.String `tfsdk:"service"`
+	Protocol types.String `tfsdk:"protocol"`
+	Port     types.Int64  `tfsdk:"port"`
+	URL      types.String `tfsdk:"url"`
+}
+
+var appEndpointType = types.ObjectType{AttrTypes: map[string]attr.Type{
+	"service":  types.StringType,
+	"protocol": types.StringType,
+	"port":     types.Int64Type,
+	"url":      types.StringType,
+}}
+
+func endpointsAttribute() schema.ListNestedAttribute {
+	return schema.ListNestedAttribute{
+		MarkdownDescription: "Ports the installed application exposes, sorted by service and port, e.g. for DNS records or monitors. Null when the NAS does not report them",
+		Computed:            true,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"service": schema.StringAttribute{
+					MarkdownDescription: "Service exposing the port",
+					Computed:            true,
+				},
+				"protocol": schema.StringAttribute{
+					MarkdownDescription: "Protocol served, e.g. `https` or `tcp`",
+					Computed:            true,
+				},
+				"port": schema.Int64Attribute{
+					MarkdownDescription: "Port the box serves it on",
+					Computed:            true,
+				},
+				"url": schema.StringAttribute{
+					MarkdownDescription: "URL it is reachable at, e.g. `https://wiki.box.heiyu.space` or `tcp://box.heiyu.space:2222`",
+					Computed:            true,
+				},
+			},
+		},
+	}
+}
+
+// readEndpoints fetches the endpoints of the installed app into data.
+// Firmware without the endpoint listing leaves them null.
+func readEndpoints(ctx context.Context, client Client, data *LpkResourceModel) diag.Diagnostics {
+	var diags diag.Diagnostics
+	data.Endpoints = types.ListNull(appEndpointType)
+	endpoints, err := userClient(client, data.UID).ListAppEndpoints(ctx, data.Appid.ValueString())
+	if errors.Is(err, errNotFound) {
+		return diags
+	}
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to list application endpoints, got error: %s", err))
+		return diags
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Service != endpoints[j].Service {
+			return endpoints[i].Service < endpoints[j].Service
+		}
+		return endpoints[i].Port < endpoints[j].Port
+	})
+	models := make([]AppEndpointModel, 0, len(endpoints))
+	for _, endpoint := range endpoints {
+		models = append(models, AppEndpointModel{
+			Service:  stringOrNull(endpoint.Service),
+			Protocol: types.StringValue(endpoint.Protocol),
+			Port:     types.Int64Value(endpoint.Port),
+			URL:      stringOrNull(endpoint.URL),
+		})
+	}
+	list, d := types.ListValueFrom(ctx, appEndpointType, models)
+	diags.Append(d...)
+	data.Endpoints = list
+	return diags
+}
@@ -0,0 +1,148 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// AppHealthCheckModel describes the HTTP check an app must pass after an
+// install or upgrade.
+type AppHealthCheckModel struct {
+	Path           types.String `tfsdk:"path"`
+	Port           types.Int64  `tfsdk:"port"`
+	ExpectedStatus types.Int64  `tfsdk:"expected_status"`
+	Interval       types.String `tfsdk:"interval"`
+	Timeout        types.String `tfsdk:"timeout"`
+}
+
+const (
+	defaultHealthInterval = 5 * time.Second
+	defaultHealthTimeout  = 5 * time.Minute
+)
+
+var healthPathPattern = regexp.MustCompile(`^/`)
+
+func healthCheckBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		MarkdownDescription: "HTTP check the NAS runs against the application after an install or upgrade. The apply fails, and with `rollback_on_failure` the change is undone, when the application does not become healthy in time",
+		Attributes: map[string]schema.Attribute{
+			"path": schema.StringAttribute{
+				MarkdownDescription: "Path requested, e.g. `/healthz`. Defaults to `/`",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(healthPathPattern, "must start with /"),
+				},
+			},
+			"port": schema.Int64Attribute{
+				MarkdownDescription: "Container port requested. Defaults to the HTTP port the application is routed to",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(1, 65535),
+				},
+			},
+			"expected_status": schema.Int64Attribute{
+				MarkdownDescription: "HTTP status code of a healthy response. Defaults to `200`",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(100, 599),
+				},
+			},
+			"interval": schema.StringAttribute{
+				MarkdownDescription: "Delay between attempts as a Go duration. Defaults to `5s`",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(durationPattern, "must be a Go duration such as 30s or 1h30m"),
+				},
+			},
+			"timeout": schema.StringAttribute{
+				MarkdownDescription: "How long to wait for a healthy response as a Go duration. Defaults to `5m`",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(durationPattern, "must be a Go duration such as 30s or 1h30m"),
+				},
+			},
+		},
+	}
+}
+
+// healthProbe is the request a health check makes and the status it expects.
+type healthProbe struct {
+	path     string
+	port     int64
+	expected int
+}
+
+// probeFor returns the probe of check, or the defaults for a nil check.
+func probeFor(check *AppHealthCheckModel) healthProbe {
+	probe := healthProbe{path: "/", expected: 200}
+	if check == nil {
+		return probe
+	}
+	if p := check.Path.ValueString(); p != "" {
+		probe.path = p
+	}
+	probe.port = check.Port.ValueInt64()
+	if !check.ExpectedStatus.IsNull() {
+		probe.expected = int(check.ExpectedStatus.ValueInt64())
+	}
+	return probe
+}
+
+// run probes appID once. It reports whether the app is healthy and, if not,
+// the last result; the error is only set when the NAS cannot probe at all.
+func (p healthProbe) run(ctx context.Context, client Client, appID string) (bool, string, error) {
+	probe, err := client.ProbeApp(ctx, appID, p.path, p.port)
+	switch {
+	case errors.Is(err, errProbeUnsupported):
+		return false, "", err
+	case err != nil:
+		return false, err.Error(), nil
+	case probe.StatusCode == p.expected:
+		return true, "", nil
+	case probe.Error != "":
+		return false, probe.Error, nil
+	default:
+		return false, fmt.Sprintf("status %d", probe.StatusCode), nil
+	}
+}
+
+// waitHealthy polls the health check of appID until it returns the expected
+// status or the check times out. A nil check passes immediately.
+func waitHealthy(ctx context.Context, client Client, appID string, check *AppHealthCheckModel) error {
+	if check == nil {
+		return nil
+	}
+	probe := probeFor(check)
+	interval := timeoutOr(check.Interval, defaultHealthInterval)
+	ctx, cancel := context.WithTimeout(ctx, timeoutOr(check.Timeout, defaultHealthTimeout))
+	defer cancel()
+	for {
+		healthy, last, err := probe.run(ctx, client, appID)
+		if err != nil {
+			return err
+		}
+		if healthy {
+			return nil
+		}
+		tflog.Debug(ctx, "waiting for application to become healthy", map[string]any{
+			"appid": appID,
+			"path":  probe.path,
+			"last":  last,
+		})
+		if err := sleepContext(ctx, interval); err != nil {
+			return fmt.Errorf("%s did not return status %d on %s in time; last result: %s", appID, probe.expected, probe.path, last)
+		}
+	}
+}
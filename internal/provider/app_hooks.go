@@ -0,0 +1,132 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// AppHookModel is a command run before or after the package of an app is
+// installed.
+type AppHookModel struct {
+	Command types.String `tfsdk:"command"`
+	RunOn   types.String `tfsdk:"run_on"`
+	Service types.String `tfsdk:"service"`
+	Timeout types.String `tfsdk:"timeout"`
+}
+
+const (
+	hookRunLocal = "local"
+	hookRunApp   = "app"
+
+	defaultHookTimeout = 10 * time.Minute
+
+	// maxHookOutput is how much of the end of a failed hook's output is
+	// reported.
+	maxHookOutput = 4096
+)
+
+func hookBlock(description string) schema.ListNestedBlock {
+	return schema.ListNestedBlock{
+		MarkdownDescription: description,
+		NestedObject: schema.NestedBlockObject{
+			Attributes: map[string]schema.Attribute{
+				"command": schema.StringAttribute{
+					MarkdownDescription: "Shell command to run with `sh -c`. Local commands get the application ID in `LCMD_APPID` once it is known",
+					Required:            true,
+					Validators: []validator.String{
+						stringvalidator.LengthAtLeast(1),
+					},
+				},
+				"run_on": schema.StringAttribute{
+					MarkdownDescription: "Where the command runs: `local` on the machine running Terraform, or `app` in a container of the installed application through the NAS. Defaults to `local`",
+					Optional:            true,
+					Validators: []validator.String{
+						stringvalidator.OneOf(hookRunLocal, hookRunApp),
+					},
+				},
+				"service": schema.StringAttribute{
+					MarkdownDescription: "Service of the application the command runs in with `run_on = \"app\"`. Defaults to the main one",
+					Optional:            true,
+				},
+				"timeout": schema.StringAttribute{
+					MarkdownDescription: "How long the command may run as a Go duration. Defaults to `10m`",
+					Optional:            true,
+					Validators: []validator.String{
+						stringvalidator.RegexMatches(durationPattern, "must be a Go duration such as 30s or 1h30m"),
+					},
+				},
+			},
+		},
+	}
+}
+
+// runHooks runs hooks in order and stops at the first that fails. phase
+// names them in errors. With an empty appID, i.e. before the first install,
+// hooks that run in the application are skipped.
+func runHooks(ctx context.Context, client Client, appID, phase string, hooks []AppHookModel) error {
+	for i, hook := range hooks {
+		runOn := hook.RunOn.ValueString()
+		if runOn == hookRunApp && appID == "" {
+			tflog.Info(ctx, "skipping hook, the application is not installed yet", map[string]any{"phase": phase, "index": i})
+			continue
+		}
+		output, err := runHook(ctx, client, appID, hook)
+		tflog.Info(ctx, "ran lifecycle hook", map[string]any{
+			"phase":  phase,
+			"index":  i,
+			"run_on": runOn,
+			"output": output,
+		})
+		if err != nil {
+			return fmt.Errorf("%s[%d] %q: %w%s", phase, i, hook.Command.ValueString(), err, hookOutputTail(output))
+		}
+	}
+	return nil
+}
+
+func runHook(ctx context.Context, client Client, appID string, hook AppHookModel) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeoutOr(hook.Timeout, defaultHookTimeout))
+	defer cancel()
+	if hook.RunOn.ValueString() == hookRunApp {
+		result, err := client.ExecApp(ctx, appID, apiExecRequest{
+			Command: hook.Command.ValueString(),
+			Service: hook.Service.ValueString(),
+		})
+		if err != nil {
+			return "", err
+		}
+		if result.ExitCode != 0 {
+			return result.Output, fmt.Errorf("exit status %d", result.ExitCode)
+		}
+		return result.Output, nil
+	}
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook.Command.ValueString())
+	if appID != "" {
+		cmd.Env = commandEnvironment(map[string]string{"LCMD_APPID": appID})
+	}
+	out, err := cmd.CombinedOutput()
+	return string(out), err
+}
+
+// hookOutputTail formats the end of output for an error message.
+func hookOutputTail(output string) string {
+	output = strings.TrimSpace(output)
+	if output == "" {
+		return ""
+	}
+	if len(output) > maxHookOutput {
+		output = "..." + output[len(output)-maxHookOutput:]
+	}
+	return "\n\nOutput:\n" + output
+}
@@ -0,0 +1,159 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// AppWindowModel limits when an app may be reinstalled or upgraded.
+type AppWindowModel struct {
+	Days     types.List   `tfsdk:"days"`
+	Start    types.String `tfsdk:"start"`
+	End      types.String `tfsdk:"end"`
+	Timezone types.String `tfsdk:"timezone"`
+	Outside  types.String `tfsdk:"outside_window"`
+}
+
+// Values of outside_window.
+const (
+	outsideWindowFail = "fail"
+	outsideWindowWait = "wait"
+)
+
+func maintenanceWindowBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		MarkdownDescription: "Only reinstall or upgrade the application while this window is open, to protect always-on services. Other changes, creating the application, and replacing it are not held back",
+		Attributes: map[string]schema.Attribute{
+			"days": schema.ListAttribute{
+				MarkdownDescription: "Days the window opens on, as `sun`, `mon`, `tue`, `wed`, `thu`, `fri`, or `sat`. Defaults to every day",
+				ElementType:         types.StringType,
+				Optional:            true,
+				Validators: []validator.List{
+					listvalidator.ValueStringsAre(stringvalidator.OneOf(windowDays...)),
+				},
+			},
+			"start": schema.StringAttribute{
+				MarkdownDescription: "Local time the window opens, as `HH:MM`",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(windowStartPattern, "must be a 24-hour time such as 02:30"),
+				},
+			},
+			"end": schema.StringAttribute{
+				MarkdownDescription: "Local time the window closes, as `HH:MM`. A time before `start` closes it the next day",
+				Required:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(windowStartPattern, "must be a 24-hour time such as 02:30"),
+				},
+			},
+			"timezone": schema.StringAttribute{
+				MarkdownDescription: "IANA time zone `start` and `end` are expressed in. Defaults to `UTC`",
+				Optional:            true,
+			},
+			"outside_window": schema.StringAttribute{
+				MarkdownDescription: "What an apply that would reinstall or upgrade outside the window does: `fail` with a diagnostic naming the next opening, or `wait` for it, bounded by the update timeout. Defaults to `fail`",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(outsideWindowFail, outsideWindowWait),
+				},
+			},
+		},
+	}
+}
+
+// window converts m into the representation of NAS maintenance windows.
+func (m *AppWindowModel) window(ctx context.Context) (apiMaintenanceWindow, error) {
+	window := apiMaintenanceWindow{
+		Name:     "maintenance_window",
+		Start:    m.Start.ValueString(),
+		Timezone: m.Timezone.ValueString(),
+	}
+	start, err := time.Parse("15:04", m.Start.ValueString())
+	if err != nil {
+		return window, err
+	}
+	end, err := time.Parse("15:04", m.End.ValueString())
+	if err != nil {
+		return window, err
+	}
+	if !end.After(start) {
+		end = end.Add(24 * time.Hour)
+	}
+	window.Duration = end.Sub(start).String()
+	if !m.Days.IsNull() && !m.Days.IsUnknown() {
+		if diags := m.Days.ElementsAs(ctx, &window.Days, false); diags.HasError() {
+			return window, errors.New("days must be a list of strings")
+		}
+	}
+	return window, nil
+}
+
+// awaitMaintenanceWindow returns once the maintenance window m is open. Outside
+// it, it fails unless outside_window is wait, in which case it sleeps until the
+// window opens, failing right away when that is after the deadline of ctx. A
+// nil m is always open.
+func awaitMaintenanceWindow(ctx context.Context, m *AppWindowModel) error {
+	if m == nil {
+		return nil
+	}
+	window, err := m.window(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	open, err := windowOpen(window, now)
+	if err != nil || open {
+		return err
+	}
+	opens, err := nextWindowOpening(window, now)
+	if err != nil {
+		return err
+	}
+	if m.Outside.ValueString() != outsideWindowWait {
+		return fmt.Errorf("the application is only reinstalled or upgraded during its maintenance window, which next opens at %s. Retry the apply then, or set outside_window = %q to wait for it", opens.Format(time.RFC3339), outsideWindowWait)
+	}
+	if deadline, ok := ctx.Deadline(); ok && deadline.Before(opens) {
+		return fmt.Errorf("the maintenance window next opens at %s, after the update times out at %s", opens.Format(time.RFC3339), deadline.In(opens.Location()).Format(time.RFC3339))
+	}
+	tflog.Info(ctx, "waiting for maintenance window", map[string]any{"opens": opens.Format(time.RFC3339)})
+	if err := sleepContext(ctx, time.Until(opens)); err != nil {
+		return fmt.Errorf("stopped waiting for the maintenance window opening at %s: %w", opens.Format(time.RFC3339), err)
+	}
+	return nil
+}
+
+// nextWindowOpening returns when window next opens after now, in the time
+// zone of the window.
+func nextWindowOpening(window apiMaintenanceWindow, now time.Time) (time.Time, error) {
+	loc := time.UTC
+	if window.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(window.Timezone); err != nil {
+			return time.Time{}, err
+		}
+	}
+	start, err := time.Parse("15:04", window.Start)
+	if err != nil {
+		return time.Time{}, err
+	}
+	local := now.In(loc)
+	for offset := 0; offset <= 7; offset++ {
+		day := local.AddDate(0, 0, offset)
+		opens := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		if windowOnDay(window.Days, opens.Weekday()) && opens.After(local) {
+			return opens, nil
+		}
+	}
+	return time.Time{}, errors.New("the maintenance window never opens")
+}
@@ -7,18 +7,34 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/booldefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/boolplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/objectplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"gopkg.in/yaml.v3"
 )
 
 // Ensure provider defined types fully satisfy framework interfaces.
 var _ resource.Resource = &AppResource{}
 var _ resource.ResourceWithImportState = &AppResource{}
+var _ resource.ResourceWithModifyPlan = &AppResource{}
+var _ resource.ResourceWithUpgradeState = &AppResource{}
+var _ resource.ResourceWithValidateConfig = &AppResource{}
 
 func NewAppResource() resource.Resource {
 	return &AppResource{}
@@ -26,19 +42,86 @@ func NewAppResource() resource.Resource {
 
 // AppResource defines the resource implementation.
 type AppResource struct {
-	client *LcmdClient
+	client Client
 }
 
 // LpkResourceModel describes the resource data model.
 type LpkResourceModel struct {
-	Title     types.String `tfsdk:"title"`
-	LpkUrl    types.String `tfsdk:"lpk_url"`
-	LpkId     types.String `tfsdk:"lpk_id"`
-	Appid     types.String `tfsdk:"appid"`
-	Version   types.String `tfsdk:"version"`
-	Domain    types.String `tfsdk:"domain"`
-	Owner     types.String `tfsdk:"owner"`
-	Ephemeral types.Bool   `tfsdk:"ephemeral"`
+	Title           types.String            `tfsdk:"title"`
+	LpkUrl          types.String            `tfsdk:"lpk_url"`
+	LpkPath         types.String            `tfsdk:"lpk_path"`
+	LpkPathSHA      types.String            `tfsdk:"lpk_path_sha256"`
+	UploadID        types.String            `tfsdk:"upload_id"`
+	PackageID       types.String            `tfsdk:"package_id"`
+	Constraint      types.String            `tfsdk:"version_constraint"`
+	Resolved        types.String            `tfsdk:"resolved_version"`
+	SHA256          types.String            `tfsdk:"sha256"`
+	Source          *AppSourceModel         `tfsdk:"source"`
+	LpkId           types.String            `tfsdk:"lpk_id"`
+	Appid           types.String            `tfsdk:"appid"`
+	Box             types.String            `tfsdk:"box"`
+	Version         types.String            `tfsdk:"version"`
+	Domain          types.String            `tfsdk:"domain"`
+	Subdomain       types.String            `tfsdk:"subdomain"`
+	CustomDomain    types.String            `tfsdk:"custom_domain"`
+	Endpoint        types.String            `tfsdk:"endpoint"`
+	Endpoints       types.List              `tfsdk:"endpoints"`
+	VisibleTo       types.Set               `tfsdk:"visible_to_users"`
+	PublicAccess    types.Bool              `tfsdk:"public_access"`
+	Owner           types.String            `tfsdk:"owner"`
+	Ephemeral       types.Bool              `tfsdk:"ephemeral"`
+	PreventData     types.Bool              `tfsdk:"prevent_destroy_data"`
+	Triggers        types.Map               `tfsdk:"triggers"`
+	Abandon         types.Bool              `tfsdk:"abandon_on_destroy"`
+	Rollback        types.Bool              `tfsdk:"rollback_on_failure"`
+	Backup          types.Bool              `tfsdk:"backup_before_update"`
+	WaitForTLS      types.Bool              `tfsdk:"wait_for_tls"`
+	Snapshot        types.String            `tfsdk:"backup_snapshot_id"`
+	Strategy        types.String            `tfsdk:"upgrade_strategy"`
+	IgnoreDrift     types.Bool              `tfsdk:"ignore_version_drift"`
+	Security        *AppSecurityModel       `tfsdk:"security"`
+	GPU             types.Bool              `tfsdk:"gpu"`
+	Instance        types.String            `tfsdk:"instance_name"`
+	Upgrade         *AppUpgradeModel        `tfsdk:"auto_upgrade"`
+	ClientLink      types.String            `tfsdk:"client_link"`
+	Status          types.String            `tfsdk:"status"`
+	InstalledAt     types.String            `tfsdk:"installed_at"`
+	UnpackedSize    types.Int64             `tfsdk:"unpacked_size"`
+	IconURL         types.String            `tfsdk:"icon_url"`
+	Priority        types.Int64             `tfsdk:"start_priority"`
+	AutoStart       types.Bool              `tfsdk:"auto_start"`
+	Teardown        types.Int64             `tfsdk:"teardown_priority"`
+	Display         types.String            `tfsdk:"display_title"`
+	DesiredState    types.String            `tfsdk:"desired_state"`
+	UID             types.String            `tfsdk:"uid"`
+	Manifest        types.String            `tfsdk:"installed_manifest"`
+	Services        types.List              `tfsdk:"services"`
+	ExpectSHA       types.String            `tfsdk:"expected_manifest_sha"`
+	Timeouts        *TimeoutsModel          `tfsdk:"timeouts"`
+	HealthCheck     *AppHealthCheckModel    `tfsdk:"health_check"`
+	Canary          *AppCanaryModel         `tfsdk:"canary"`
+	Window          *AppWindowModel         `tfsdk:"maintenance_window"`
+	PreInstall      []AppHookModel          `tfsdk:"pre_install"`
+	PostInstall     []AppHookModel          `tfsdk:"post_install"`
+	Resources       *AppResourceLimitsModel `tfsdk:"resources"`
+	Environment     types.Map               `tfsdk:"environment"`
+	ConfigFiles     []AppConfigFileModel    `tfsdk:"config_files"`
+	Labels          types.Map               `tfsdk:"labels"`
+	EffectiveLabels types.Map               `tfsdk:"effective_labels"`
+}
+
+// AppUpgradeModel describes the NAS auto-update policy of the app.
+type AppUpgradeModel struct {
+	Enabled  types.Bool   `tfsdk:"enabled"`
+	Channel  types.String `tfsdk:"channel"`
+	Schedule types.String `tfsdk:"schedule"`
+}
+
+// AppSecurityModel describes container security options applied at install time.
+type AppSecurityModel struct {
+	ReadOnlyRootfs  types.Bool `tfsdk:"read_only_rootfs"`
+	NoNewPrivileges types.Bool `tfsdk:"no_new_privileges"`
+	CapDrop         types.List `tfsdk:"cap_drop"`
 }
 
 func (r *AppResource) Metadata(ctx context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -46,14 +129,78 @@ func (r *AppResource) Metadata(ctx context.Context, req resource.MetadataRequest
 }
 
 func (r *AppResource) Schema(ctx context.Context, req resource.SchemaRequest, resp *resource.SchemaResponse) {
+	labels, effectiveLabels := labelsAttributes("application", func() Client { return r.client })
 	resp.Schema = schema.Schema{
 		// This description is used by the documentation generator and the language server.
 		MarkdownDescription: "LPK application resource for managing LPK packages on the system",
+		Version:             appSchemaVersion,
 
 		Attributes: map[string]schema.Attribute{
 			"lpk_url": schema.StringAttribute{
-				MarkdownDescription: "URL of the LPK package to install",
-				Required:            true,
+				MarkdownDescription: "URL of the LPK package to install. Exactly one of `lpk_url`, `lpk_path`, `package_id`, and `source` must be set; with `lpk_path` or `package_id` this is the URL of the uploaded or resolved package",
+				Optional:            true,
+				Computed:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("lpk_url"), path.MatchRoot("lpk_path"), path.MatchRoot("package_id"), path.MatchRoot("source")),
+				},
+			},
+			"package_id": schema.StringAttribute{
+				MarkdownDescription: "Appid of a package in the NAS registry to install instead of `lpk_url`, at the newest version matching `version_constraint`",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"version_constraint": schema.StringAttribute{
+				MarkdownDescription: "Versions of `package_id` that may be installed, e.g. `~> 1.4` or `>= 1.2, < 2.0`, in the syntax of Terraform version constraints. Defaults to the newest release. The resolved version stays pinned in `resolved_version` while it matches, also when newer matching versions are published; changing the constraint resolves it again",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("package_id")),
+				},
+			},
+			"resolved_version": schema.StringAttribute{
+				MarkdownDescription: "Version of `package_id` that `version_constraint` resolved to",
+				Computed:            true,
+			},
+			"lpk_path": schema.StringAttribute{
+				MarkdownDescription: "Local `.lpk` file, e.g. built by external tooling, to upload to the registry and install instead of `lpk_url`. A changed file is uploaded again and applied according to `upgrade_strategy`",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"lpk_path_sha256": schema.StringAttribute{
+				MarkdownDescription: "SHA256 of the file at `lpk_path` when it was last uploaded",
+				Computed:            true,
+			},
+			"upload_id": schema.StringAttribute{
+				MarkdownDescription: "ID of the package uploaded from `lpk_path`. It is deleted from the registry once it is no longer installed",
+				Computed:            true,
+			},
+			"sha256": schema.StringAttribute{
+				MarkdownDescription: "Hex-encoded SHA256 the package at `lpk_url` must have. It is checked before every install, using a checksum header the server returns for `HEAD` or else by downloading the package, and a mismatch fails the apply. A refresh that finds different content at `lpk_url` only warns, since the installed application is left in place",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(sha256Pattern, "must be a hex-encoded SHA256"),
+				},
+			},
+			"source":           sourceAttribute(),
+			"box":              boxAttribute(),
+			"labels":           labels,
+			"effective_labels": effectiveLabels,
+			"environment":      environmentAttribute(),
+			"uid": schema.StringAttribute{
+				MarkdownDescription: "NAS user the application is installed for. Defaults to the provider `user`; changing it reinstalls the application",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
 			},
 			"lpk_id": schema.StringAttribute{
 				MarkdownDescription: "ID of the LPK package",
@@ -75,32 +222,256 @@ func (r *AppResource) Schema(ctx context.Context, req resource.SchemaRequest, re
 				MarkdownDescription: "Domain of the LPK package",
 				Computed:            true,
 			},
+			"subdomain": schema.StringAttribute{
+				MarkdownDescription: "Subdomain the application is served under on the box, instead of the one declared in the package",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(subdomainPattern, "must be a lowercase DNS label"),
+				},
+			},
+			"custom_domain": schema.StringAttribute{
+				MarkdownDescription: "Additional domain routed to the application, e.g. `wiki.example.com`. Its DNS must already point at the box",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(customDomainPattern, "must be a lowercase domain name"),
+				},
+			},
+			"endpoint": schema.StringAttribute{
+				MarkdownDescription: "URL the application is reachable at, on `custom_domain` when set",
+				Computed:            true,
+			},
+			"endpoints":        endpointsAttribute(),
+			"visible_to_users": visibleToUsersAttribute(),
+			"public_access":    publicAccessAttribute(),
 			"owner": schema.StringAttribute{
 				MarkdownDescription: "Owner of the LPK package",
 				Computed:            true,
 			},
+			"client_link": schema.StringAttribute{
+				MarkdownDescription: "Deep link that opens or installs the application in the Lazycat mobile and desktop clients",
+				Computed:            true,
+			},
+			"status": schema.StringAttribute{
+				MarkdownDescription: "Install status the NAS reports for the application, e.g. `installed` or `error`",
+				Computed:            true,
+			},
+			"installed_at": schema.StringAttribute{
+				MarkdownDescription: "When the installed version was installed, in RFC 3339 format",
+				Computed:            true,
+			},
+			"unpacked_size": schema.Int64Attribute{
+				MarkdownDescription: "Disk space the unpacked package takes on the box in bytes, without its data",
+				Computed:            true,
+			},
+			"icon_url": schema.StringAttribute{
+				MarkdownDescription: "URL of the application icon",
+				Computed:            true,
+			},
+			"installed_manifest": schema.StringAttribute{
+				MarkdownDescription: "Manifest of the installed package as normalized JSON, when the NAS exposes it",
+				Computed:            true,
+			},
+			"services": servicesAttribute(),
+			"expected_manifest_sha": schema.StringAttribute{
+				MarkdownDescription: "SHA256 of the normalized manifest the installed package must match, e.g. `lcmd_lpk_build.app.manifest_sha256`. A mismatch on refresh is reported as drift and the package is reinstalled",
+				Optional:            true,
+			},
 			"ephemeral": schema.BoolAttribute{
 				MarkdownDescription: "Whether the LPK is ephemeral",
 				Optional:            true,
 				Computed:            true,
 				Default:             booldefault.StaticBool(false),
 			},
+			"triggers": schema.MapAttribute{
+				MarkdownDescription: "Arbitrary values whose change reinstalls the package according to `upgrade_strategy`, e.g. a checksum of the package behind a stable `lpk_url` such as `latest.lpk`",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+			"prevent_destroy_data": schema.BoolAttribute{
+				MarkdownDescription: "Keep the application data when it is uninstalled. Conflicts with `ephemeral = true`. Like `abandon_on_destroy` it only protects a destroy once it has been applied",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"abandon_on_destroy": schema.BoolAttribute{
+				MarkdownDescription: "Only remove the application from state on destroy, leaving it installed with its data. This also applies to replacements, whose new installation then runs next to the abandoned one",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"rollback_on_failure": schema.BoolAttribute{
+				MarkdownDescription: "Reinstall the previously installed `lpk_url` when an upgrade or its `health_check` fails, and uninstall a new application that fails its `health_check`. State always records what is left installed, also when the rollback itself fails",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"wait_for_tls": schema.BoolAttribute{
+				MarkdownDescription: "After an install, reinstall, or route change, wait until `endpoint` presents a certificate that validates on the machine running Terraform, so resources using the domain do not hit certificate errors. Gives up after 10 minutes, or the resource timeout when it is shorter, and fails the apply",
+				Optional:            true,
+			},
+			"backup_before_update": schema.BoolAttribute{
+				MarkdownDescription: "Snapshot the application data through the NAS backup API before every reinstall or upgrade, once `pre_install` has run. A failed snapshot aborts the apply before the application is changed",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"backup_snapshot_id": schema.StringAttribute{
+				MarkdownDescription: "ID of the snapshot `backup_before_update` took before the last reinstall or upgrade, to restore the data from in the NAS UI. It is kept when the upgrade fails",
+				Computed:            true,
+			},
+			"ignore_version_drift": schema.BoolAttribute{
+				MarkdownDescription: "Accept versions installed outside Terraform, e.g. through the NAS UI. By default a refresh that finds a different version than Terraform installed warns and plans a reinstall of `lpk_url`. Implied while `auto_upgrade` is enabled",
+				Optional:            true,
+				Computed:            true,
+				Default:             booldefault.StaticBool(false),
+			},
+			"teardown_priority": schema.Int64Attribute{
+				MarkdownDescription: "Uninstall order among applications destroyed in the same apply. An uninstall waits for those with a lower priority to finish, e.g. `0` for the apps using a database and `10` for the database itself. Dependencies in the configuration still take precedence",
+				Optional:            true,
+			},
+			"upgrade_strategy": schema.StringAttribute{
+				MarkdownDescription: "How a changed `lpk_url` is applied: `reinstall` uninstalls the application before installing the new package, `in_place` installs it over the running application so its data and sessions are kept, and `blue_green` installs it as a second instance under a staging subdomain, runs `health_check` against it, then swaps the routes over and uninstalls the old instance, so the application stays available. With `blue_green` a failed check leaves the old instance serving, and the package must support running twice. Defaults to `reinstall`",
+				Optional:            true,
+				Computed:            true,
+				Default:             stringdefault.StaticString(upgradeReinstall),
+				Validators: []validator.String{
+					stringvalidator.OneOf(upgradeInPlace, upgradeReinstall, upgradeBlueGreen),
+				},
+			},
+			"start_priority": schema.Int64Attribute{
+				MarkdownDescription: "Boot-time start order where the NAS supports it. Apps with a lower priority start first, e.g. databases before the apps that use them",
+				Optional:            true,
+			},
+			"auto_start": schema.BoolAttribute{
+				MarkdownDescription: "Whether the application is started when the box boots. A setting changed in the NAS UI shows up as drift. Unset leaves it to the NAS",
+				Optional:            true,
+			},
+			"display_title": schema.StringAttribute{
+				MarkdownDescription: "Name the application is shown under in the NAS UI instead of the package title, e.g. to tell several instances of the same app apart",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"desired_state": schema.StringAttribute{
+				MarkdownDescription: "Runtime state the application is kept in: `running`, `stopped`, or `paused`. A state changed in the NAS UI shows up as drift. Unset leaves the state to the NAS",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(appStates...),
+				},
+			},
+			"auto_upgrade": schema.SingleNestedAttribute{
+				MarkdownDescription: "Auto-update policy of the NAS for this application. Changes made in the NAS UI show up as drift. Removing the attribute leaves the current policy in place",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"enabled": schema.BoolAttribute{
+						MarkdownDescription: "Whether the NAS upgrades the application automatically",
+						Required:            true,
+					},
+					"channel": schema.StringAttribute{
+						MarkdownDescription: "Release channel upgrades are taken from, e.g. `stable` or `beta`. Defaults to the NAS setting",
+						Optional:            true,
+						Computed:            true,
+						Validators: []validator.String{
+							stringvalidator.LengthAtLeast(1),
+						},
+					},
+					"schedule": schema.StringAttribute{
+						MarkdownDescription: "Five-field cron expression, in the NAS time zone, for when upgrades are checked, e.g. `0 4 * * *`. Defaults to the NAS setting",
+						Optional:            true,
+						Computed:            true,
+						Validators: []validator.String{
+							stringvalidator.RegexMatches(cronPattern, "must be a five-field cron expression"),
+						},
+					},
+				},
+			},
+			"instance_name": schema.StringAttribute{
+				MarkdownDescription: "Name of this installation when the same package is installed several times on a box, e.g. `staging` next to `production`. The application ID gets the suffix `-<instance_name>`. Changing it reinstalls the application",
+				Optional:            true,
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(instanceNamePattern, "must be a lowercase DNS label of at most 32 characters"),
+				},
+			},
+			"gpu": schema.BoolAttribute{
+				MarkdownDescription: "Give the application access to the GPU of the box, e.g. for AI workloads. The install fails on boxes without one. Changing it reinstalls the application",
+				Optional:            true,
+				PlanModifiers: []planmodifier.Bool{
+					boolplanmodifier.RequiresReplace(),
+				},
+			},
+			"security": schema.SingleNestedAttribute{
+				MarkdownDescription: "Container security options applied at install time where the runtime supports them. Changing them reinstalls the application",
+				Optional:            true,
+				PlanModifiers: []planmodifier.Object{
+					objectplanmodifier.RequiresReplace(),
+				},
+				Attributes: map[string]schema.Attribute{
+					"read_only_rootfs": schema.BoolAttribute{
+						MarkdownDescription: "Mount the container root filesystem read-only",
+						Optional:            true,
+					},
+					"no_new_privileges": schema.BoolAttribute{
+						MarkdownDescription: "Prevent processes from gaining additional privileges",
+						Optional:            true,
+					},
+					"cap_drop": schema.ListAttribute{
+						MarkdownDescription: "Linux capabilities to drop, e.g. `NET_RAW`",
+						ElementType:         types.StringType,
+						Optional:            true,
+					},
+				},
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"timeouts":           timeoutsBlock(),
+			"health_check":       healthCheckBlock(),
+			"canary":             canaryBlock(),
+			"maintenance_window": maintenanceWindowBlock(),
+			"pre_install":        hookBlock("Commands run in order before every install or reinstall of the package, e.g. to back up a database before an upgrade. A failing command aborts the apply before the application is changed. Commands with `run_on = \"app\"` run in the installed version and are skipped on the first install"),
+			"post_install":       hookBlock("Commands run in order after every install or reinstall once the application is configured and, with `health_check`, healthy, e.g. database migrations or cache warmups. A failing command fails the apply with its output"),
+			"resources":          resourceLimitsBlock(),
+			"config_files":       configFilesBlock(),
 		},
 	}
 }
 
+func (r *AppResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data LpkResourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if data.PreventData.ValueBool() && data.Ephemeral.ValueBool() {
+		resp.Diagnostics.AddAttributeError(path.Root("ephemeral"), "Conflicting deletion settings", "ephemeral = true clears the application data on uninstall, which prevent_destroy_data forbids.")
+	}
+	if w := data.Window; w != nil {
+		if !w.Timezone.IsNull() && !w.Timezone.IsUnknown() {
+			if _, err := time.LoadLocation(w.Timezone.ValueString()); err != nil {
+				resp.Diagnostics.AddAttributeError(path.Root("maintenance_window").AtName("timezone"), "Invalid timezone", err.Error())
+			}
+		}
+		if !w.Start.IsUnknown() && w.Start.Equal(w.End) {
+			resp.Diagnostics.AddAttributeError(path.Root("maintenance_window").AtName("end"), "Empty maintenance window", "end must differ from start.")
+		}
+	}
+}
+
 func (r *AppResource) Configure(ctx context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
 	// Prevent panic if the provider has not been configured.
 	if req.ProviderData == nil {
 		return
 	}
 
-	client, ok := req.ProviderData.(*LcmdClient)
+	client, ok := req.ProviderData.(Client)
 
 	if !ok {
 		resp.Diagnostics.AddError(
 			"Unexpected Resource Configure Type",
-			fmt.Sprintf("Expected *LcmdClient, got: %T. Please report this issue to the provider developers.", req.ProviderData),
+			fmt.Sprintf("Expected Client, got: %T. Please report this issue to the provider developers.", req.ProviderData),
 		)
 
 		return
@@ -109,6 +480,66 @@ func (r *AppResource) Configure(ctx context.Context, req resource.ConfigureReque
 	r.client = client
 }
 
+// ModifyPlan hashes the file at lpk_path so a changed package is uploaded
+// again, and keeps the upload attributes null when lpk_url is used. When the
+// installed version drifted from the one Terraform installed, it plans the
+// recorded version back, which Update applies by reinstalling lpk_url.
+func (r *AppResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var plan, state LpkResourceModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if !req.State.Raw.IsNull() {
+		resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	}
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	switch {
+	case plan.LpkPath.IsNull():
+		plan.LpkPathSHA = types.StringNull()
+		plan.UploadID = types.StringNull()
+	case plan.LpkPath.IsUnknown():
+		plan.LpkUrl = types.StringUnknown()
+		plan.LpkPathSHA = types.StringUnknown()
+		plan.UploadID = types.StringUnknown()
+	default:
+		sha, err := computeSHA(plan.LpkPath.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Hash error", err.Error())
+			return
+		}
+		if plan.LpkPath.Equal(state.LpkPath) && sha == state.LpkPathSHA.ValueString() {
+			plan.LpkUrl = state.LpkUrl
+			plan.UploadID = state.UploadID
+		} else {
+			plan.LpkUrl = types.StringUnknown()
+			plan.UploadID = types.StringUnknown()
+		}
+		plan.LpkPathSHA = types.StringValue(sha)
+	}
+	if fromAppStore(&plan) {
+		plan.LpkUrl = types.StringNull()
+	}
+	if plan.PackageID.IsNull() {
+		plan.Resolved = types.StringNull()
+	} else {
+		resp.Diagnostics.Append(r.resolvePackage(ctx, &plan, &state, !req.State.Raw.IsNull())...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	if !req.State.Raw.IsNull() && !ignoresVersionDrift(&plan) && !plan.LpkUrl.IsUnknown() && plan.LpkUrl.Equal(state.LpkUrl) {
+		expected, diags := expectedVersion(ctx, req.Private)
+		resp.Diagnostics.Append(diags...)
+		if expected != "" && expected != state.Version.ValueString() {
+			plan.Version = types.StringValue(expected)
+		}
+	}
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
 func (r *AppResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
 	var data LpkResourceModel
 
@@ -116,19 +547,133 @@ func (r *AppResource) Create(ctx context.Context, req resource.CreateRequest, re
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	client := boxClient(r.client, data.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	ctx, cancel := data.Timeouts.create(ctx)
+	defer cancel()
+	data.Snapshot = types.StringNull()
 
-	app, err := r.client.InstallApp(ctx, data.LpkUrl.ValueString(), true, data.Ephemeral.ValueBool())
+	// Without a configured provider at plan time the version is resolved
+	// now.
+	if !data.PackageID.IsNull() && data.LpkUrl.IsUnknown() {
+		resp.Diagnostics.Append(r.resolvePackage(ctx, &data, &data, false)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	if !data.LpkPath.IsNull() {
+		if err := uploadPackage(ctx, userClient(client, data.UID), &data); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to upload LPK, got error: %s", err))
+			return
+		}
+	}
+	if err := verifyPackage(ctx, client, &data); err != nil {
+		resp.Diagnostics.AddError("Package verification failed", err.Error())
+		return
+	}
+	if err := runHooks(ctx, userClient(client, data.UID), "", "pre_install", data.PreInstall); err != nil {
+		resp.Diagnostics.AddError("Hook failed", err.Error())
+		return
+	}
+	install, diags := installRequest(ctx, &data)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	app, err := userClient(client, data.UID).InstallApp(ctx, install)
 	if err != nil {
 		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to install LPK, got error: %s", err))
 		return
 	}
 
-	data.LpkId = stringOrNull(app.LpkID)
-	data.Title = stringOrNull(app.Title)
-	data.Version = stringOrNull(app.Version)
-	data.Domain = stringOrNull(app.Domain)
-	data.Appid = stringOrNull(app.AppID)
-	data.Owner = stringOrNull(app.Owner)
+	applyAppInfo(&data, app)
+	resp.Diagnostics.Append(recordVersion(ctx, resp.Private, app.Version)...)
+	resp.Diagnostics.Append(r.readManifest(ctx, client, &data, false)...)
+	if !data.Priority.IsNull() {
+		if err := userClient(client, data.UID).SetStartPriority(ctx, data.Appid.ValueString(), data.Priority.ValueInt64()); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set start priority, got error: %s", err))
+			data.Priority = types.Int64Null()
+		}
+	}
+	if !data.AutoStart.IsNull() {
+		if err := userClient(client, data.UID).SetAutoStart(ctx, data.Appid.ValueString(), data.AutoStart.ValueBool()); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set auto start, got error: %s", err))
+			data.AutoStart = types.BoolNull()
+		}
+	}
+	if !data.Display.IsNull() {
+		if err := userClient(client, data.UID).SetDisplayTitle(ctx, data.Appid.ValueString(), data.Display.ValueString()); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set display title, got error: %s", err))
+			data.Display = types.StringNull()
+		}
+	}
+	if !data.Subdomain.IsNull() || !data.CustomDomain.IsNull() {
+		app, err := userClient(client, data.UID).SetAppRoute(ctx, data.Appid.ValueString(), data.Subdomain.ValueString(), data.CustomDomain.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set application route, got error: %s", err))
+			data.Subdomain = types.StringNull()
+			data.CustomDomain = types.StringNull()
+		} else {
+			applyAppInfo(&data, app)
+		}
+	}
+	if data.Resources != nil {
+		if err := setResourceLimits(ctx, userClient(client, data.UID), &data); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set resource limits, got error: %s", err))
+			data.Resources = nil
+		}
+	}
+	if hasAppAccess(&data) {
+		if err := setAppAccess(ctx, userClient(client, data.UID), &data); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set application access, got error: %s", err))
+			data.VisibleTo = types.SetNull(types.StringType)
+			data.PublicAccess = types.BoolNull()
+		}
+	}
+	if hasAppConfig(&data) {
+		if err := setAppConfig(ctx, userClient(client, data.UID), &data); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to configure application, got error: %s", err))
+			data.Environment = types.MapNull(types.StringType)
+			data.ConfigFiles = nil
+		}
+	}
+	if !data.DesiredState.IsNull() {
+		if err := userClient(client, data.UID).SetAppState(ctx, data.Appid.ValueString(), data.DesiredState.ValueString()); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set application state, got error: %s", err))
+			data.DesiredState = types.StringNull()
+		}
+	}
+	if data.Upgrade != nil {
+		if err := setAutoUpgrade(ctx, userClient(client, data.UID), &data); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set auto-upgrade policy, got error: %s", err))
+			data.Upgrade = nil
+		}
+	}
+
+	resp.Diagnostics.Append(readEndpoints(ctx, client, &data)...)
+
+	if err := waitHealthy(ctx, userClient(client, data.UID), data.Appid.ValueString(), data.HealthCheck); err != nil {
+		if !data.Rollback.ValueBool() {
+			resp.Diagnostics.AddError("Health check failed", err.Error())
+		} else if delErr := userClient(client, data.UID).DeleteApp(ctx, data.Appid.ValueString(), false); delErr != nil {
+			resp.Diagnostics.AddError("Health check failed", fmt.Sprintf("%s; uninstalling the application also failed: %s", err, delErr))
+		} else {
+			resp.Diagnostics.AddError("Health check failed", fmt.Sprintf("%s. The application was uninstalled.", err))
+			return
+		}
+	}
+	if data.WaitForTLS.ValueBool() && !resp.Diagnostics.HasError() {
+		if err := waitForTLS(ctx, data.Endpoint.ValueString()); err != nil {
+			resp.Diagnostics.AddError("Certificate not issued", err.Error())
+		}
+	}
+	if !resp.Diagnostics.HasError() {
+		if err := runHooks(ctx, userClient(client, data.UID), data.Appid.ValueString(), "post_install", data.PostInstall); err != nil {
+			resp.Diagnostics.AddError("Hook failed", err.Error())
+		}
+	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
 }
@@ -144,8 +689,12 @@ func (r *AppResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 		resp.State.RemoveResource(ctx)
 		return
 	}
+	client := boxClient(r.client, state.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
 
-	app, err := r.client.GetApp(ctx, state.Appid.ValueString())
+	app, err := userClient(client, state.UID).GetApp(ctx, state.Appid.ValueString())
 	if errors.Is(err, errNotFound) {
 		resp.State.RemoveResource(ctx)
 		return
@@ -155,12 +704,99 @@ func (r *AppResource) Read(ctx context.Context, req resource.ReadRequest, resp *
 		return
 	}
 
-	state.LpkId = stringOrNull(app.LpkID)
-	state.Title = stringOrNull(app.Title)
-	state.Version = stringOrNull(app.Version)
-	state.Domain = stringOrNull(app.Domain)
-	state.Appid = stringOrNull(app.AppID)
-	state.Owner = stringOrNull(app.Owner)
+	version, diags := expectedVersion(ctx, req.Private)
+	resp.Diagnostics.Append(diags...)
+	switch {
+	case ignoresVersionDrift(&state):
+		resp.Diagnostics.Append(recordVersion(ctx, resp.Private, app.Version)...)
+	case version != "" && app.Version != version:
+		resp.Diagnostics.AddWarning("Installed version drift", fmt.Sprintf("%s was installed at version %s but the NAS reports %s. %s will be reinstalled on the next apply unless ignore_version_drift is set.", state.Appid.ValueString(), version, app.Version, state.LpkUrl.ValueString()))
+	}
+	applyAppInfo(&state, app)
+	if app.Security != nil {
+		security, diags := securityModel(ctx, state.Security, app.Security)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		state.Security = security
+	}
+	if app.StartPriority != nil {
+		state.Priority = types.Int64Value(*app.StartPriority)
+	}
+	if app.GPU != nil {
+		state.GPU = boolOrNull(state.GPU, *app.GPU)
+	}
+	if app.AutoStart != nil && !state.AutoStart.IsNull() {
+		state.AutoStart = types.BoolValue(*app.AutoStart)
+	}
+	if app.Labels != nil {
+		effective, diags := types.MapValueFrom(ctx, types.StringType, app.Labels)
+		resp.Diagnostics.Append(diags...)
+		if len(app.Labels) > 0 || !state.EffectiveLabels.IsNull() {
+			state.EffectiveLabels = effective
+		}
+	}
+	// A title set outside Terraform is only reported once display_title is
+	// managed, so unmanaged apps do not show a perpetual diff.
+	if !state.Display.IsNull() {
+		state.Display = stringOrNull(app.DisplayTitle)
+	}
+	if !state.Subdomain.IsNull() {
+		state.Subdomain = stringOrNull(app.Subdomain)
+	}
+	if !state.CustomDomain.IsNull() {
+		state.CustomDomain = stringOrNull(app.CustomDomain)
+	}
+	// Transitional states such as starting are not drift; the next refresh
+	// settles them.
+	if !state.DesiredState.IsNull() && slices.Contains(appStates, app.State) {
+		state.DesiredState = types.StringValue(app.State)
+	}
+	if state.Upgrade != nil {
+		policy, err := userClient(client, state.UID).GetAutoUpgrade(ctx, state.Appid.ValueString())
+		if err != nil && !errors.Is(err, errNotFound) {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read auto-upgrade policy, got error: %s", err))
+			return
+		}
+		if policy != nil {
+			applyAutoUpgrade(&state, policy)
+		}
+	}
+	if state.Resources != nil {
+		limits, err := userClient(client, state.UID).GetAppResources(ctx, state.Appid.ValueString())
+		if err != nil && !errors.Is(err, errNotFound) {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read resource limits, got error: %s", err))
+			return
+		}
+		if limits != nil {
+			applyResourceLimits(&state, limits)
+		}
+	}
+	if hasAppAccess(&state) {
+		access, err := userClient(client, state.UID).GetAppAccess(ctx, state.Appid.ValueString())
+		if err != nil && !errors.Is(err, errNotFound) {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read application access, got error: %s", err))
+			return
+		}
+		if access != nil {
+			applyAppAccess(&state, access)
+		}
+	}
+	if !state.SHA256.IsNull() {
+		sum, err := client.PackageSHA256(ctx, state.LpkUrl.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddWarning("Unable to verify package", fmt.Sprintf("Checking the checksum of %s failed: %s", state.LpkUrl.ValueString(), err))
+		} else if !strings.EqualFold(sum, state.SHA256.ValueString()) {
+			resp.Diagnostics.AddWarning("Package content changed", fmt.Sprintf("The package at %s now has SHA256 %s instead of %s. The installed application is kept, but installs from lpk_url will fail until sha256 or lpk_url is updated.", state.LpkUrl.ValueString(), sum, state.SHA256.ValueString()))
+		}
+	}
+	expected := state.ExpectSHA
+	resp.Diagnostics.Append(r.readManifest(ctx, client, &state, true)...)
+	resp.Diagnostics.Append(readEndpoints(ctx, client, &state)...)
+	if !expected.Equal(state.ExpectSHA) {
+		resp.Diagnostics.AddWarning("Installed manifest drift", fmt.Sprintf("The manifest installed for %s has SHA256 %s, but %s was expected. The package will be reinstalled on the next apply.", state.Appid.ValueString(), state.ExpectSHA.ValueString(), expected.ValueString()))
+	}
 
 	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
 }
@@ -174,40 +810,351 @@ func (r *AppResource) Update(ctx context.Context, req resource.UpdateRequest, re
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	client := boxClient(r.client, plan.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	ctx, cancel := plan.Timeouts.update(ctx)
+	defer cancel()
 
-	if plan.LpkUrl.ValueString() != state.LpkUrl.ValueString() {
-		if !state.Appid.IsNull() && state.Appid.ValueString() != "" {
-			if err := r.client.DeleteApp(ctx, state.Appid.ValueString(), false); err != nil {
+	if !plan.PackageID.IsNull() && plan.LpkUrl.IsUnknown() {
+		resp.Diagnostics.Append(r.resolvePackage(ctx, &plan, &state, true)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	if !plan.LpkPath.IsNull() && plan.LpkUrl.IsUnknown() {
+		if err := uploadPackage(ctx, userClient(client, plan.UID), &plan); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to upload LPK, got error: %s", err))
+			return
+		}
+		// An upload that does not end up installed, e.g. after a rollback,
+		// is removed again.
+		uploaded := plan.UploadID.ValueString()
+		defer func() {
+			var current types.String
+			resp.State.GetAttribute(ctx, path.Root("upload_id"), &current)
+			if current.ValueString() != uploaded {
+				if err := userClient(client, plan.UID).DeleteLPK(ctx, uploaded); err != nil && !errors.Is(err, errNotFound) {
+					resp.Diagnostics.AddWarning("Delete superseded upload failed", err.Error())
+				}
+			}
+		}()
+	}
+	// A known planned version that differs from state is version drift
+	// planned back by ModifyPlan.
+	drifted := !plan.Version.IsUnknown() && !plan.Version.Equal(state.Version)
+	reinstall := drifted || plan.LpkUrl.ValueString() != state.LpkUrl.ValueString() || !plan.LpkPathSHA.Equal(state.LpkPathSHA) || !plan.ExpectSHA.Equal(state.ExpectSHA) || !plan.Triggers.Equal(state.Triggers) || !sameSource(plan.Source, state.Source)
+	plan.Snapshot = state.Snapshot
+	if reinstall {
+		installed := !state.Appid.IsNull() && state.Appid.ValueString() != ""
+		inPlace := plan.Strategy.ValueString() == upgradeInPlace && installed
+		blueGreen := plan.Strategy.ValueString() == upgradeBlueGreen && installed
+		if installed {
+			if err := awaitMaintenanceWindow(ctx, plan.Window); err != nil {
+				resp.Diagnostics.AddError("Outside maintenance window", err.Error())
+				return
+			}
+		}
+		if err := verifyPackage(ctx, client, &plan); err != nil {
+			resp.Diagnostics.AddError("Package verification failed", err.Error())
+			return
+		}
+		if err := runHooks(ctx, userClient(client, state.UID), state.Appid.ValueString(), "pre_install", plan.PreInstall); err != nil {
+			resp.Diagnostics.AddError("Hook failed", err.Error())
+			return
+		}
+		if plan.Backup.ValueBool() && installed {
+			snapshot, err := userClient(client, state.UID).SnapshotApp(ctx, state.Appid.ValueString())
+			if err != nil {
+				resp.Diagnostics.AddError("Backup failed", fmt.Sprintf("Unable to snapshot %s before the upgrade, got error: %s", state.Appid.ValueString(), err))
+				return
+			}
+			tflog.Info(ctx, "snapshotted application data", map[string]any{"appid": state.Appid.ValueString(), "snapshot_id": snapshot.ID})
+			// Recorded right away, so the snapshot is found again when
+			// the upgrade fails.
+			plan.Snapshot = types.StringValue(snapshot.ID)
+			state.Snapshot = plan.Snapshot
+			resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("backup_snapshot_id"), plan.Snapshot)...)
+		}
+		if !inPlace && !blueGreen && installed {
+			if err := userClient(client, state.UID).DeleteApp(ctx, state.Appid.ValueString(), false); err != nil {
 				resp.Diagnostics.AddError("Uninstall failed", err.Error())
 				return
 			}
 		}
 
-		app, err := r.client.InstallApp(ctx, plan.LpkUrl.ValueString(), true, plan.Ephemeral.ValueBool())
+		install, diags := installRequest(ctx, &plan)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		var app *apiAppInfo
+		var err error
+		if blueGreen {
+			app, err = blueGreenDeploy(ctx, userClient(client, plan.UID), state.Appid.ValueString(), install, plan.HealthCheck, plan.Canary, &resp.Diagnostics)
+			if err != nil {
+				resp.Diagnostics.AddError("Blue/green deployment failed", err.Error())
+				return
+			}
+		} else if inPlace {
+			app, err = userClient(client, plan.UID).UpgradeApp(ctx, state.Appid.ValueString(), install)
+			if errors.Is(err, errUpgradeUnsupported) {
+				resp.Diagnostics.AddError("Upgrade failed", fmt.Sprintf("%s. Set upgrade_strategy = %q to uninstall and reinstall the application instead.", err, upgradeReinstall))
+				return
+			}
+		} else {
+			app, err = userClient(client, plan.UID).InstallApp(ctx, install)
+		}
 		if err != nil {
+			if plan.Rollback.ValueBool() && !state.LpkUrl.IsNull() && state.LpkUrl.ValueString() != "" {
+				r.rollback(ctx, client, &state, inPlace, err, resp)
+				return
+			}
 			resp.Diagnostics.AddError("Install failed", err.Error())
 			return
 		}
 
-		plan.LpkId = stringOrNull(app.LpkID)
-		plan.Title = stringOrNull(app.Title)
-		plan.Version = stringOrNull(app.Version)
-		plan.Domain = stringOrNull(app.Domain)
-		plan.Appid = stringOrNull(app.AppID)
-		plan.Owner = stringOrNull(app.Owner)
+		wantVersion := plan.Version
+		applyAppInfo(&plan, app)
+		resp.Diagnostics.Append(recordVersion(ctx, resp.Private, app.Version)...)
+		if drifted && !wantVersion.Equal(plan.Version) {
+			resp.Diagnostics.AddError("Version drift not reverted", fmt.Sprintf("Reinstalling %s installed version %s instead of %s; lpk_url no longer points at the package Terraform installed. Update lpk_url or set ignore_version_drift.", plan.LpkUrl.ValueString(), app.Version, wantVersion.ValueString()))
+		}
+		resp.Diagnostics.Append(r.readManifest(ctx, client, &plan, false)...)
+		// Blue/green deployments checked the staged instance before it served.
+		if !blueGreen {
+			err := waitHealthy(ctx, userClient(client, plan.UID), plan.Appid.ValueString(), plan.HealthCheck)
+			canaryFailed := false
+			if err == nil && installed {
+				err = bakeCanary(ctx, userClient(client, plan.UID), plan.Appid.ValueString(), plan.HealthCheck, plan.Canary)
+				canaryFailed = err != nil
+			}
+			if err != nil {
+				if (!plan.Rollback.ValueBool() && !canaryFailed) || state.LpkUrl.IsNull() || state.LpkUrl.ValueString() == "" {
+					resp.Diagnostics.AddError("Health check failed", err.Error())
+				} else {
+					// Without in-place upgrades the unhealthy app has to go before
+					// the previous package can be installed again.
+					if !inPlace {
+						if err := userClient(client, plan.UID).DeleteApp(ctx, plan.Appid.ValueString(), false); err != nil {
+							resp.Diagnostics.AddError("Uninstall failed", fmt.Sprintf("Unable to uninstall the unhealthy upgrade before rolling back: %s", err))
+							resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+							return
+						}
+					}
+					r.rollback(ctx, client, &state, inPlace, err, resp)
+					return
+				}
+			}
+		}
 	} else {
 		plan.LpkId = state.LpkId
 		plan.Title = state.Title
 		plan.Version = state.Version
 		plan.Domain = state.Domain
+		plan.Endpoint = state.Endpoint
 		plan.Appid = state.Appid
 		plan.Owner = state.Owner
+		plan.ClientLink = state.ClientLink
+		plan.Status = state.Status
+		plan.InstalledAt = state.InstalledAt
+		plan.UnpackedSize = state.UnpackedSize
+		plan.IconURL = state.IconURL
+		plan.Manifest = state.Manifest
+		plan.Services = state.Services
+	}
+
+	if !plan.Priority.IsNull() && (reinstall || !plan.Priority.Equal(state.Priority)) {
+		if err := userClient(client, plan.UID).SetStartPriority(ctx, plan.Appid.ValueString(), plan.Priority.ValueInt64()); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set start priority, got error: %s", err))
+			if !reinstall {
+				return
+			}
+			plan.Priority = state.Priority
+		}
+	}
+
+	if !plan.AutoStart.IsNull() && (reinstall || !plan.AutoStart.Equal(state.AutoStart)) {
+		if err := userClient(client, plan.UID).SetAutoStart(ctx, plan.Appid.ValueString(), plan.AutoStart.ValueBool()); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set auto start, got error: %s", err))
+			if !reinstall {
+				return
+			}
+			plan.AutoStart = types.BoolNull()
+		}
+	}
+
+	routed := !plan.Subdomain.IsNull() || !plan.CustomDomain.IsNull()
+	if (reinstall && routed) || !plan.Subdomain.Equal(state.Subdomain) || !plan.CustomDomain.Equal(state.CustomDomain) {
+		app, err := userClient(client, plan.UID).SetAppRoute(ctx, plan.Appid.ValueString(), plan.Subdomain.ValueString(), plan.CustomDomain.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set application route, got error: %s", err))
+			if !reinstall {
+				return
+			}
+			plan.Subdomain = types.StringNull()
+			plan.CustomDomain = types.StringNull()
+		} else {
+			applyAppInfo(&plan, app)
+		}
+	}
+
+	if (reinstall && plan.Resources != nil) || (!reinstall && !sameResourceLimits(plan.Resources, state.Resources)) {
+		if err := setResourceLimits(ctx, userClient(client, plan.UID), &plan); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set resource limits, got error: %s", err))
+			if !reinstall {
+				return
+			}
+			plan.Resources = nil
+		}
+	}
+
+	if hasAppAccess(&plan) && (reinstall || !sameAppAccess(&plan, &state)) {
+		if err := setAppAccess(ctx, userClient(client, plan.UID), &plan); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set application access, got error: %s", err))
+			if !reinstall {
+				return
+			}
+			plan.VisibleTo = types.SetNull(types.StringType)
+			plan.PublicAccess = types.BoolNull()
+		}
+	}
+
+	if (reinstall && hasAppConfig(&plan)) || (!reinstall && !sameAppConfig(&plan, &state)) {
+		if err := setAppConfig(ctx, userClient(client, plan.UID), &plan); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to configure application, got error: %s", err))
+			if !reinstall {
+				return
+			}
+			plan.Environment = types.MapNull(types.StringType)
+			plan.ConfigFiles = nil
+		}
+	}
+
+	if !plan.DesiredState.IsNull() && (reinstall || !plan.DesiredState.Equal(state.DesiredState)) {
+		if err := userClient(client, plan.UID).SetAppState(ctx, plan.Appid.ValueString(), plan.DesiredState.ValueString()); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set application state, got error: %s", err))
+			if !reinstall {
+				return
+			}
+			plan.DesiredState = types.StringNull()
+		}
+	}
+
+	if plan.Upgrade != nil && (reinstall || !sameAutoUpgrade(plan.Upgrade, state.Upgrade)) {
+		if err := setAutoUpgrade(ctx, userClient(client, plan.UID), &plan); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set auto-upgrade policy, got error: %s", err))
+			if !reinstall {
+				return
+			}
+			plan.Upgrade = nil
+		}
+	} else if plan.Upgrade != nil {
+		plan.Upgrade = state.Upgrade
+	}
+
+	if !reinstall && !plan.EffectiveLabels.Equal(state.EffectiveLabels) {
+		labels, diags := labelsMap(ctx, plan.EffectiveLabels)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if err := userClient(client, plan.UID).SetAppLabels(ctx, plan.Appid.ValueString(), labels); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set labels, got error: %s", err))
+			return
+		}
+	}
+
+	// A reinstall resets the display title, and clearing the attribute
+	// restores the package title.
+	if !plan.Display.Equal(state.Display) || (reinstall && !plan.Display.IsNull()) {
+		if err := userClient(client, plan.UID).SetDisplayTitle(ctx, plan.Appid.ValueString(), plan.Display.ValueString()); err != nil {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set display title, got error: %s", err))
+			if !reinstall {
+				return
+			}
+			plan.Display = types.StringNull()
+		}
+	}
+
+	if !state.UploadID.IsNull() && state.UploadID.ValueString() != "" && state.UploadID.ValueString() != plan.UploadID.ValueString() {
+		if err := userClient(client, state.UID).DeleteLPK(ctx, state.UploadID.ValueString()); err != nil && !errors.Is(err, errNotFound) {
+			resp.Diagnostics.AddWarning("Delete superseded upload failed", err.Error())
+		}
+	}
+
+	// Reinstalls and route changes can both move the endpoints.
+	resp.Diagnostics.Append(readEndpoints(ctx, client, &plan)...)
+
+	// A new domain needs a new certificate.
+	if plan.WaitForTLS.ValueBool() && (reinstall || !plan.Endpoint.Equal(state.Endpoint)) && !resp.Diagnostics.HasError() {
+		if err := waitForTLS(ctx, plan.Endpoint.ValueString()); err != nil {
+			resp.Diagnostics.AddError("Certificate not issued", err.Error())
+		}
+	}
+
+	if reinstall && !resp.Diagnostics.HasError() {
+		if err := runHooks(ctx, userClient(client, plan.UID), plan.Appid.ValueString(), "post_install", plan.PostInstall); err != nil {
+			resp.Diagnostics.AddError("Hook failed", err.Error())
+		}
 	}
 
 	plan.Ephemeral = types.BoolValue(plan.Ephemeral.ValueBool())
 	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
 }
 
+// rollback reinstalls the package recorded in prior state after a failed
+// upgrade, over the existing installation when the upgrade was in place. The
+// upgrade error is still reported so the apply fails, but state is set to the
+// restored installation so it matches what is running on the box.
+func (r *AppResource) rollback(ctx context.Context, client Client, state *LpkResourceModel, inPlace bool, upgradeErr error, resp *resource.UpdateResponse) {
+	tflog.Warn(ctx, "upgrade failed, rolling back", map[string]any{
+		"lpk_url": state.LpkUrl.ValueString(),
+		"error":   upgradeErr.Error(),
+	})
+	install, diags := installRequest(ctx, state)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var app *apiAppInfo
+	err := verifyPackage(ctx, client, state)
+	switch {
+	case err != nil:
+	case inPlace:
+		app, err = userClient(client, state.UID).UpgradeApp(ctx, state.Appid.ValueString(), install)
+	default:
+		app, err = userClient(client, state.UID).InstallApp(ctx, install)
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Install failed", fmt.Sprintf("Upgrade failed: %s; rollback to %s also failed: %s", upgradeErr, state.LpkUrl.ValueString(), err))
+		// Record whatever is left installed, so the next apply retries the
+		// upgrade against it rather than installing a second copy.
+		current, getErr := userClient(client, state.UID).GetApp(ctx, state.Appid.ValueString())
+		if getErr != nil {
+			resp.State.RemoveResource(ctx)
+			return
+		}
+		applyAppInfo(state, current)
+		resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+		return
+	}
+
+	previous := state.Version.ValueString()
+	applyAppInfo(state, app)
+	resp.Diagnostics.Append(recordVersion(ctx, resp.Private, app.Version)...)
+	resp.Diagnostics.Append(r.readManifest(ctx, client, state, true)...)
+	resp.Diagnostics.Append(readEndpoints(ctx, client, state)...)
+
+	resp.Diagnostics.AddWarning("Upgrade rolled back", fmt.Sprintf("Reinstalled previous package %s (version %s) after the upgrade failed.", state.LpkUrl.ValueString(), app.Version))
+	if previous != "" && app.Version != previous {
+		resp.Diagnostics.AddWarning("Rollback version mismatch", fmt.Sprintf("The previous package was recorded as version %s but the NAS reports %s after reinstalling it; lpk_url may no longer point at the same package.", previous, app.Version))
+	}
+	resp.Diagnostics.AddError("Install failed", upgradeErr.Error())
+	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
+}
+
 func (r *AppResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
 	var data LpkResourceModel
 
@@ -216,23 +1163,363 @@ func (r *AppResource) Delete(ctx context.Context, req resource.DeleteRequest, re
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	if data.Abandon.ValueBool() {
+		resp.Diagnostics.AddWarning("Application abandoned", fmt.Sprintf("abandon_on_destroy is set, so %s was removed from state but is still installed on the NAS.", data.Appid.ValueString()))
+		return
+	}
+	client := boxClient(r.client, data.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	ctx, cancel := data.Timeouts.delete(ctx)
+	defer cancel()
 
 	if !data.Appid.IsNull() && data.Appid.ValueString() != "" {
-		if err := r.client.DeleteApp(ctx, data.Appid.ValueString(), data.Ephemeral.ValueBool()); err != nil {
+		if !data.Teardown.IsNull() {
+			release, err := client.AwaitTeardown(ctx, data.Teardown.ValueInt64())
+			if err != nil {
+				resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to uninstall LPK, got error: %s", err))
+				return
+			}
+			defer release()
+		}
+		clearData := data.Ephemeral.ValueBool() && !data.PreventData.ValueBool()
+		if err := userClient(client, data.UID).DeleteApp(ctx, data.Appid.ValueString(), clearData); err != nil {
 			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to uninstall LPK, got error: %s", err))
 			return
 		}
 	}
+	if !data.UploadID.IsNull() && data.UploadID.ValueString() != "" {
+		if err := userClient(client, data.UID).DeleteLPK(ctx, data.UploadID.ValueString()); err != nil && !errors.Is(err, errNotFound) {
+			resp.Diagnostics.AddError("Delete upload failed", err.Error())
+			return
+		}
+	}
 
 	tflog.Trace(ctx, "deleted LPK package", map[string]any{
 		"lpk_url": data.LpkUrl.ValueString(),
 	})
 }
 
+// UpgradeState migrates state written by releases before schema versioning.
+func (r *AppResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	var current resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &current)
+	return map[int64]resource.StateUpgrader{
+		0: rawStateUpgrader(current.Schema),
+	}
+}
+
 func (r *AppResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
 	resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
 }
 
+// resolvePackage plans lpk_url and resolved_version for the package_id of
+// plan. The version in state is kept while package_id and
+// version_constraint are unchanged and it still matches, so a plan only
+// moves to a newer version when asked to.
+func (r *AppResource) resolvePackage(ctx context.Context, plan, state *LpkResourceModel, exists bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if plan.PackageID.IsUnknown() || plan.Constraint.IsUnknown() || r.client == nil {
+		plan.LpkUrl = types.StringUnknown()
+		plan.Resolved = types.StringUnknown()
+		return diags
+	}
+	var constraint versionConstraint
+	if !plan.Constraint.IsNull() {
+		var err error
+		if constraint, err = parseConstraint(plan.Constraint.ValueString()); err != nil {
+			diags.AddAttributeError(path.Root("version_constraint"), "Invalid version constraint", err.Error())
+			return diags
+		}
+	}
+	if exists && plan.PackageID.Equal(state.PackageID) && plan.Constraint.Equal(state.Constraint) && !state.Resolved.IsNull() {
+		if pinned, err := parseVersion(state.Resolved.ValueString()); err == nil && constraint.allows(pinned) {
+			plan.LpkUrl = state.LpkUrl
+			plan.Resolved = state.Resolved
+			return diags
+		}
+	}
+	client := boxClient(r.client, plan.Box, &diags)
+	if diags.HasError() {
+		return diags
+	}
+	versions, err := client.ListPackageVersions(ctx, plan.PackageID.ValueString())
+	if errors.Is(err, errNotFound) {
+		diags.AddAttributeError(path.Root("package_id"), "Package not found", fmt.Sprintf("The registry has no package %s.", plan.PackageID.ValueString()))
+		return diags
+	}
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to list versions of %s, got error: %s", plan.PackageID.ValueString(), err))
+		return diags
+	}
+	names := make([]string, len(versions))
+	for i, v := range versions {
+		names[i] = v.Version
+	}
+	i := constraint.newestAllowed(names)
+	if i < 0 {
+		diags.AddAttributeError(path.Root("version_constraint"), "No matching version", fmt.Sprintf("None of the %d versions of %s in the registry match %q.", len(versions), plan.PackageID.ValueString(), plan.Constraint.ValueString()))
+		return diags
+	}
+	plan.LpkUrl = types.StringValue(versions[i].LPKURL)
+	plan.Resolved = types.StringValue(versions[i].Version)
+	return diags
+}
+
+// readManifest fetches the installed manifest, and the services it declares,
+// into data. When an expected manifest SHA is configured and the installed
+// manifest differs from it, a refresh replaces the SHA in data with the
+// installed one so the mismatch shows up as a planned change; after an
+// install it is an error. Firmware without the manifest endpoint leaves the
+// manifest null.
+func (r *AppResource) readManifest(ctx context.Context, client Client, data *LpkResourceModel, refresh bool) diag.Diagnostics {
+	var diags diag.Diagnostics
+	data.Services = types.ListNull(appServiceType)
+	raw, err := userClient(client, data.UID).GetAppManifest(ctx, data.Appid.ValueString())
+	if errors.Is(err, errNotFound) {
+		data.Manifest = types.StringNull()
+		return diags
+	}
+	if err != nil {
+		diags.AddError("Client Error", fmt.Sprintf("Unable to read installed manifest, got error: %s", err))
+		return diags
+	}
+	normalized, sha, err := normalizeManifest(raw)
+	if err != nil {
+		diags.AddError("Invalid manifest", fmt.Sprintf("Unable to parse the manifest reported by the NAS: %s", err))
+		return diags
+	}
+	data.Manifest = types.StringValue(normalized)
+	services, d := manifestServices(ctx, raw)
+	diags.Append(d...)
+	data.Services = services
+	if data.ExpectSHA.IsNull() || data.ExpectSHA.IsUnknown() || data.ExpectSHA.ValueString() == sha {
+		return diags
+	}
+	if !refresh {
+		diags.AddError("Installed manifest mismatch", fmt.Sprintf("The package installed from %s has manifest SHA256 %s, but expected_manifest_sha is %s.", data.LpkUrl.ValueString(), sha, data.ExpectSHA.ValueString()))
+		return diags
+	}
+	data.ExpectSHA = types.StringValue(sha)
+	return diags
+}
+
+// uploadPackage uploads the file at lpk_path and points lpk_url at it.
+func uploadPackage(ctx context.Context, client Client, data *LpkResourceModel) error {
+	lpkPath := data.LpkPath.ValueString()
+	_, manifestData, err := readLPKArchive(lpkPath)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", lpkPath, err)
+	}
+	var manifest manifestYAML
+	if manifestData != nil {
+		if err := yaml.Unmarshal(manifestData, &manifest); err != nil {
+			return fmt.Errorf("parse manifest in %s: %w", lpkPath, err)
+		}
+	}
+	labels, diags := labelsMap(ctx, data.EffectiveLabels)
+	if diags.HasError() {
+		return errors.New("convert effective_labels")
+	}
+	upload, err := client.UploadLPK(ctx, apiUploadRequest{
+		UID:     client.UID(),
+		Name:    manifest.Name,
+		Version: manifest.Version,
+		Labels:  labels,
+	}, lpkPath)
+	if err != nil {
+		return err
+	}
+	data.LpkUrl = types.StringValue(upload.DownloadURL)
+	data.UploadID = types.StringValue(upload.ID)
+	return nil
+}
+
+// setAppConfig pushes the environment and config files of data, restarting
+// the application.
+func setAppConfig(ctx context.Context, client Client, data *LpkResourceModel) error {
+	config, diags := appConfigRequest(ctx, data)
+	if diags.HasError() {
+		return errors.New("convert environment")
+	}
+	return client.SetAppConfig(ctx, data.Appid.ValueString(), config)
+}
+
+// ignoresVersionDrift reports whether versions installed outside Terraform
+// are accepted for data. Store apps are upgraded by the store, so their
+// version is never pinned.
+func ignoresVersionDrift(data *LpkResourceModel) bool {
+	return data.IgnoreDrift.ValueBool() || (data.Upgrade != nil && data.Upgrade.Enabled.ValueBool()) || fromAppStore(data)
+}
+
+// verifyPackage checks the package at lpk_url against sha256, when set.
+func verifyPackage(ctx context.Context, client Client, data *LpkResourceModel) error {
+	if data.SHA256.IsNull() || data.SHA256.IsUnknown() {
+		return nil
+	}
+	sum, err := client.PackageSHA256(ctx, data.LpkUrl.ValueString())
+	if err != nil {
+		return fmt.Errorf("unable to compute the SHA256 of %s: %w", data.LpkUrl.ValueString(), err)
+	}
+	if !strings.EqualFold(sum, data.SHA256.ValueString()) {
+		return fmt.Errorf("the package at %s has SHA256 %s, but sha256 is %s", data.LpkUrl.ValueString(), sum, data.SHA256.ValueString())
+	}
+	return nil
+}
+
+// appStates are the values of desired_state.
+var appStates = []string{"running", "stopped", "paused"}
+
+// Values of upgrade_strategy.
+const (
+	upgradeInPlace   = "in_place"
+	upgradeReinstall = "reinstall"
+	upgradeBlueGreen = "blue_green"
+)
+
+// installRequest builds the install payload for the application described by data.
+func installRequest(ctx context.Context, data *LpkResourceModel) (apiInstallRequest, diag.Diagnostics) {
+	install := apiInstallRequest{
+		LPKURL:    data.LpkUrl.ValueString(),
+		Wait:      true,
+		Ephemeral: data.Ephemeral.ValueBool(),
+	}
+	if fromAppStore(data) {
+		install.StoreAppID = data.Source.AppStore.AppID.ValueString()
+		install.Channel = data.Source.AppStore.Channel.ValueString()
+	}
+	labels, diags := labelsMap(ctx, data.EffectiveLabels)
+	install.Labels = labels
+	if data.Security != nil {
+		security := &apiSecurityOptions{
+			ReadOnlyRootfs:  data.Security.ReadOnlyRootfs.ValueBool(),
+			NoNewPrivileges: data.Security.NoNewPrivileges.ValueBool(),
+		}
+		if !data.Security.CapDrop.IsNull() && !data.Security.CapDrop.IsUnknown() {
+			diags.Append(data.Security.CapDrop.ElementsAs(ctx, &security.CapDrop, false)...)
+		}
+		install.Security = security
+	}
+	install.GPU = data.GPU.ValueBool()
+	if !data.Instance.IsNull() {
+		install.AppIDSuffix = "-" + data.Instance.ValueString()
+	}
+	return install, diags
+}
+
+// securityModel converts the security options reported by the NAS into the
+// resource model so that out-of-band changes surface as drift. Options left
+// unset in prior stay null while the NAS reports them at their defaults.
+func securityModel(ctx context.Context, prior *AppSecurityModel, security *apiSecurityOptions) (*AppSecurityModel, diag.Diagnostics) {
+	if prior == nil && !security.ReadOnlyRootfs && !security.NoNewPrivileges && len(security.CapDrop) == 0 {
+		return nil, nil
+	}
+	if prior == nil {
+		prior = &AppSecurityModel{
+			ReadOnlyRootfs:  types.BoolNull(),
+			NoNewPrivileges: types.BoolNull(),
+			CapDrop:         types.ListNull(types.StringType),
+		}
+	}
+	model := &AppSecurityModel{
+		ReadOnlyRootfs:  boolOrNull(prior.ReadOnlyRootfs, security.ReadOnlyRootfs),
+		NoNewPrivileges: boolOrNull(prior.NoNewPrivileges, security.NoNewPrivileges),
+		CapDrop:         prior.CapDrop,
+	}
+	var diags diag.Diagnostics
+	if len(security.CapDrop) > 0 {
+		model.CapDrop, diags = types.ListValueFrom(ctx, types.StringType, security.CapDrop)
+	} else if !prior.CapDrop.IsNull() {
+		model.CapDrop = types.ListValueMust(types.StringType, nil)
+	}
+	return model, diags
+}
+
+// cronPattern loosely matches a five-field cron expression; the NAS does the
+// full validation.
+var cronPattern = regexp.MustCompile(`^\S+(\s+\S+){4}$`)
+
+// subdomainPattern matches a DNS label; customDomainPattern a name of at
+// least two of them.
+var (
+	subdomainPattern    = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+	customDomainPattern = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?\.)+[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+)
+
+// instanceNamePattern matches a DNS label short enough to suffix an appid.
+var instanceNamePattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,30}[a-z0-9])?$`)
+
+// sha256Pattern matches a hex-encoded SHA256.
+var sha256Pattern = regexp.MustCompile(`^[0-9a-fA-F]{64}$`)
+
+// setAutoUpgrade applies the configured auto-upgrade policy and records the
+// effective one, which fills in channel and schedule when they are not set.
+func setAutoUpgrade(ctx context.Context, client Client, data *LpkResourceModel) error {
+	policy, err := client.SetAutoUpgrade(ctx, data.Appid.ValueString(), apiAutoUpgrade{
+		Enabled:  data.Upgrade.Enabled.ValueBool(),
+		Channel:  data.Upgrade.Channel.ValueString(),
+		Schedule: data.Upgrade.Schedule.ValueString(),
+	})
+	if err != nil {
+		return err
+	}
+	applyAutoUpgrade(data, policy)
+	return nil
+}
+
+func applyAutoUpgrade(data *LpkResourceModel, policy *apiAutoUpgrade) {
+	data.Upgrade = &AppUpgradeModel{
+		Enabled:  types.BoolValue(policy.Enabled),
+		Channel:  stringOrNull(policy.Channel),
+		Schedule: stringOrNull(policy.Schedule),
+	}
+}
+
+// sameAutoUpgrade reports whether plan asks for the policy already in state.
+// Unknown channel and schedule are left to the NAS and always match.
+func sameAutoUpgrade(plan, state *AppUpgradeModel) bool {
+	if state == nil {
+		return false
+	}
+	return plan.Enabled.Equal(state.Enabled) &&
+		(plan.Channel.IsUnknown() || plan.Channel.Equal(state.Channel)) &&
+		(plan.Schedule.IsUnknown() || plan.Schedule.Equal(state.Schedule))
+}
+
+// boolOrNull returns val, keeping prior null when val is the zero value.
+func boolOrNull(prior types.Bool, val bool) types.Bool {
+	if prior.IsNull() && !val {
+		return prior
+	}
+	return types.BoolValue(val)
+}
+
+// applyAppInfo copies the details reported by the NAS into the resource model.
+func applyAppInfo(data *LpkResourceModel, app *apiAppInfo) {
+	data.LpkId = stringOrNull(app.LpkID)
+	data.Title = stringOrNull(app.Title)
+	data.Version = stringOrNull(app.Version)
+	data.Domain = stringOrNull(app.Domain)
+	data.Appid = stringOrNull(app.AppID)
+	data.Owner = stringOrNull(app.Owner)
+	data.ClientLink = stringOrNull(app.ClientLink)
+	data.Status = stringOrNull(app.Status)
+	data.InstalledAt = stringOrNull(app.InstalledAt)
+	data.IconURL = stringOrNull(app.IconURL)
+	data.UnpackedSize = types.Int64PointerValue(app.UnpackedSize)
+	switch {
+	case app.Endpoint != "":
+		data.Endpoint = types.StringValue(app.Endpoint)
+	case app.CustomDomain != "":
+		data.Endpoint = types.StringValue("https://" + app.CustomDomain)
+	case app.Domain != "":
+		data.Endpoint = types.StringValue("https://" + app.Domain)
+	default:
+		data.Endpoint = types.StringNull()
+	}
+}
+
 func stringOrNull(val string) types.String {
 	if val == "" {
 		return types.StringNull()
@@ -0,0 +1,88 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// AppResourceLimitsModel caps the CPU and memory all containers of an app
+// may use together.
+type AppResourceLimitsModel struct {
+	CPULimit    types.Float64 `tfsdk:"cpu_limit"`
+	MemoryLimit types.Int64   `tfsdk:"memory_limit"`
+}
+
+// minMemoryLimit is the smallest memory limit the container runtime accepts.
+const minMemoryLimit = 6 << 20
+
+func resourceLimitsBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		MarkdownDescription: "CPU and memory limits applied to all containers of the application together after install. Changes made on the NAS show up as drift. Removing the block lifts the limits",
+		Attributes: map[string]schema.Attribute{
+			"cpu_limit": schema.Float64Attribute{
+				MarkdownDescription: "Number of CPU cores the application may use, e.g. `0.5`. Unset means no limit",
+				Optional:            true,
+				Validators: []validator.Float64{
+					float64validator.AtLeast(0.01),
+				},
+			},
+			"memory_limit": schema.Int64Attribute{
+				MarkdownDescription: "Memory the application may use in bytes, e.g. `536870912` for 512 MiB. Unset means no limit",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(minMemoryLimit),
+				},
+			},
+		},
+	}
+}
+
+// setResourceLimits applies the limits of data, or lifts them when data has
+// none, and records the resulting ones.
+func setResourceLimits(ctx context.Context, client Client, data *LpkResourceModel) error {
+	var request apiAppResources
+	if data.Resources != nil {
+		request.CPULimit = data.Resources.CPULimit.ValueFloat64()
+		request.MemoryLimitBytes = data.Resources.MemoryLimit.ValueInt64()
+	}
+	limits, err := client.SetAppResources(ctx, data.Appid.ValueString(), request)
+	if err != nil {
+		return err
+	}
+	applyResourceLimits(data, limits)
+	return nil
+}
+
+// applyResourceLimits records limits in data when it manages them. A limit
+// the NAS reports as absent stays null.
+func applyResourceLimits(data *LpkResourceModel, limits *apiAppResources) {
+	if data.Resources == nil {
+		return
+	}
+	data.Resources = &AppResourceLimitsModel{
+		CPULimit:    types.Float64Null(),
+		MemoryLimit: types.Int64Null(),
+	}
+	if limits.CPULimit > 0 {
+		data.Resources.CPULimit = types.Float64Value(limits.CPULimit)
+	}
+	if limits.MemoryLimitBytes > 0 {
+		data.Resources.MemoryLimit = types.Int64Value(limits.MemoryLimitBytes)
+	}
+}
+
+// sameResourceLimits reports whether plan asks for the limits already in
+// state.
+func sameResourceLimits(plan, state *AppResourceLimitsModel) bool {
+	if plan == nil || state == nil {
+		return plan == state
+	}
+	return plan.CPULimit.Equal(state.CPULimit) && plan.MemoryLimit.Equal(state.MemoryLimit)
+}
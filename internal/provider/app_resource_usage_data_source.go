@@ -0,0 +1,137 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &AppResourceUsageDataSource{}
+
+type AppResourceUsageDataSource struct {
+	client Client
+}
+
+type AppResourceUsageDataSourceModel struct {
+	ID               types.String  `tfsdk:"id"`
+	AppID            types.String  `tfsdk:"appid"`
+	Box              types.String  `tfsdk:"box"`
+	UID              types.String  `tfsdk:"uid"`
+	CPUPercent       types.Float64 `tfsdk:"cpu_percent"`
+	MemoryBytes      types.Int64   `tfsdk:"memory_bytes"`
+	MemoryLimitBytes types.Int64   `tfsdk:"memory_limit_bytes"`
+	NetworkRxBytes   types.Int64   `tfsdk:"network_rx_bytes"`
+	NetworkTxBytes   types.Int64   `tfsdk:"network_tx_bytes"`
+	SampledAt        types.String  `tfsdk:"sampled_at"`
+}
+
+func NewAppResourceUsageDataSource() datasource.DataSource {
+	return &AppResourceUsageDataSource{}
+}
+
+func (d *AppResourceUsageDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_app_resource_usage"
+}
+
+func (d *AppResourceUsageDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Samples the current CPU, memory, and network usage of an installed application. Every read takes a new sample.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Application ID the sample was taken for.",
+			},
+			"appid": schema.StringAttribute{
+				Required:    true,
+				Description: "Application ID to sample.",
+			},
+			"box": schema.StringAttribute{
+				Optional:    true,
+				Description: "Name of the entry in the provider boxes setting the application runs on. Defaults to the provider endpoint.",
+			},
+			"uid": schema.StringAttribute{
+				Optional:    true,
+				Description: "NAS user the application is installed for. Defaults to the provider user.",
+			},
+			"cpu_percent": schema.Float64Attribute{
+				Computed:    true,
+				Description: "CPU usage in percent of one core, so multi-threaded apps can exceed 100.",
+			},
+			"memory_bytes": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Resident memory in bytes.",
+			},
+			"memory_limit_bytes": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Memory limit in bytes. Null when the application is not limited.",
+			},
+			"network_rx_bytes": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Bytes received since the application started.",
+			},
+			"network_tx_bytes": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Bytes sent since the application started.",
+			},
+			"sampled_at": schema.StringAttribute{
+				Computed:    true,
+				Description: "Time the sample was taken in RFC3339 format.",
+			},
+		},
+	}
+}
+
+func (d *AppResourceUsageDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	client, ok := req.ProviderData.(Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected Client, got %T", req.ProviderData))
+		return
+	}
+	d.client = client
+}
+
+func (d *AppResourceUsageDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var data AppResourceUsageDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(d.client, data.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	appID := data.AppID.ValueString()
+	usage, err := userClient(client, data.UID).GetAppUsage(ctx, appID)
+	if errors.Is(err, errNotFound) {
+		resp.Diagnostics.AddError("Application not found", fmt.Sprintf("No usage is reported for %s; it is not installed or the NAS does not expose resource usage.", appID))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read resource usage, got error: %s", err))
+		return
+	}
+	data.ID = types.StringValue(appID)
+	data.CPUPercent = types.Float64Value(usage.CPUPercent)
+	data.MemoryBytes = types.Int64Value(usage.MemoryBytes)
+	data.MemoryLimitBytes = types.Int64Null()
+	if usage.MemoryLimitBytes > 0 {
+		data.MemoryLimitBytes = types.Int64Value(usage.MemoryLimitBytes)
+	}
+	data.NetworkRxBytes = types.Int64Value(usage.NetworkRxBytes)
+	data.NetworkTxBytes = types.Int64Value(usage.NetworkTxBytes)
+	data.SampledAt = stringOrNull(usage.SampledAt)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
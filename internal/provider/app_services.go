@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v3"
+)
+
+// AppServiceModel is a runtime service declared in the installed manifest.
+type AppServiceModel struct {
+	Name      types.String `tfsdk:"name"`
+	Image     types.String `tfsdk:"image"`
+	DependsOn types.List   `tfsdk:"depends_on"`
+}
+
+var appServiceAttrTypes = map[string]attr.Type{
+	"name":       types.StringType,
+	"image":      types.StringType,
+	"depends_on": types.ListType{ElemType: types.StringType},
+}
+
+var appServiceType = types.ObjectType{AttrTypes: appServiceAttrTypes}
+
+func servicesAttribute() schema.ListNestedAttribute {
+	return schema.ListNestedAttribute{
+		MarkdownDescription: "Runtime services declared in the installed manifest, such as the databases and caches the application runs next to it, sorted by name. Null when the NAS does not expose the manifest",
+		Computed:            true,
+		NestedObject: schema.NestedAttributeObject{
+			Attributes: map[string]schema.Attribute{
+				"name": schema.StringAttribute{
+					MarkdownDescription: "Service name, e.g. `postgres`",
+					Computed:            true,
+				},
+				"image": schema.StringAttribute{
+					MarkdownDescription: "Container image the service runs, e.g. `registry.lazycat.cloud/postgres:16`",
+					Computed:            true,
+				},
+				"depends_on": schema.ListAttribute{
+					MarkdownDescription: "Other services this service waits for, sorted by name",
+					ElementType:         types.StringType,
+					Computed:            true,
+				},
+			},
+		},
+	}
+}
+
+// manifestServices lists the services of a manifest. depends_on may be a
+// list of names or, compose-style, a map keyed by them.
+func manifestServices(ctx context.Context, manifest []byte) (types.List, diag.Diagnostics) {
+	var doc struct {
+		Services map[string]struct {
+			Image     string    `yaml:"image"`
+			DependsOn yaml.Node `yaml:"depends_on"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal(manifest, &doc); err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Invalid manifest", "Unable to read services from the manifest reported by the NAS: "+err.Error())
+		return types.ListNull(appServiceType), diags
+	}
+	names := make([]string, 0, len(doc.Services))
+	for name := range doc.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	services := make([]AppServiceModel, 0, len(names))
+	var diags diag.Diagnostics
+	for _, name := range names {
+		service := doc.Services[name]
+		var depends []string
+		switch service.DependsOn.Kind {
+		case yaml.SequenceNode:
+			for _, item := range service.DependsOn.Content {
+				depends = append(depends, item.Value)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(service.DependsOn.Content); i += 2 {
+				depends = append(depends, service.DependsOn.Content[i].Value)
+			}
+		}
+		sort.Strings(depends)
+		dependsOn, d := types.ListValueFrom(ctx, types.StringType, depends)
+		diags.Append(d...)
+		services = append(services, AppServiceModel{
+			Name:      types.StringValue(name),
+			Image:     stringOrNull(service.Image),
+			DependsOn: dependsOn,
+		})
+	}
+	list, d := types.ListValueFrom(ctx, appServiceType, services)
+	diags.Append(d...)
+	return list, diags
+}
@@ -0,0 +1,163 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
+	"github.com/hashicorp/terraform-plugin-framework/ephemeral/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ ephemeral.EphemeralResource = &AppSessionTokenEphemeralResource{}
+var _ ephemeral.EphemeralResourceWithClose = &AppSessionTokenEphemeralResource{}
+
+const defaultAppTokenTTL = 15 * time.Minute
+
+// appTokenPrivateKey is the private data key holding what Close needs to
+// revoke the token.
+const appTokenPrivateKey = "app_token"
+
+type AppSessionTokenEphemeralResource struct {
+	client Client
+}
+
+type AppSessionTokenModel struct {
+	AppID     types.String `tfsdk:"appid"`
+	Box       types.String `tfsdk:"box"`
+	UID       types.String `tfsdk:"uid"`
+	TTL       types.String `tfsdk:"ttl"`
+	Token     types.String `tfsdk:"token"`
+	ExpiresAt types.String `tfsdk:"expires_at"`
+}
+
+// appTokenPrivate is stored in private data between Open and Close.
+type appTokenPrivate struct {
+	Box   string `json:"box,omitempty"`
+	UID   string `json:"uid,omitempty"`
+	AppID string `json:"appid"`
+	ID    string `json:"id"`
+}
+
+func NewAppSessionTokenEphemeralResource() ephemeral.EphemeralResource {
+	return &AppSessionTokenEphemeralResource{}
+}
+
+func (r *AppSessionTokenEphemeralResource) Metadata(_ context.Context, req ephemeral.MetadataRequest, resp *ephemeral.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_app_session_token"
+}
+
+func (r *AppSessionTokenEphemeralResource) Schema(_ context.Context, _ ephemeral.SchemaRequest, resp *ephemeral.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Mints a short-lived token for the API of an installed application, e.g. to configure the app with another provider in the same run. The token is never stored in state and is revoked when Terraform is done with it.",
+		Attributes: map[string]schema.Attribute{
+			"appid": schema.StringAttribute{
+				Required:    true,
+				Description: "Application ID to mint the token for.",
+			},
+			"box": schema.StringAttribute{
+				Optional:    true,
+				Description: "Name of the entry in the provider boxes setting the application runs on. Defaults to the provider endpoint.",
+			},
+			"uid": schema.StringAttribute{
+				Optional:    true,
+				Description: "NAS user the application is installed for and the token acts as. Defaults to the provider user.",
+			},
+			"ttl": schema.StringAttribute{
+				Optional:    true,
+				Description: "Lifetime of the token as a Go duration, e.g. 30m. Defaults to 15m.",
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(durationPattern, "must be a Go duration such as 30s or 1h30m"),
+				},
+			},
+			"token": schema.StringAttribute{
+				Computed:    true,
+				Sensitive:   true,
+				Description: "The minted token.",
+			},
+			"expires_at": schema.StringAttribute{
+				Computed:    true,
+				Description: "Time the token expires in RFC3339 format.",
+			},
+		},
+	}
+}
+
+func (r *AppSessionTokenEphemeralResource) Configure(_ context.Context, req ephemeral.ConfigureRequest, resp *ephemeral.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	client, ok := req.ProviderData.(Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Ephemeral Resource Configure Type", fmt.Sprintf("Expected Client, got %T", req.ProviderData))
+		return
+	}
+	r.client = client
+}
+
+func (r *AppSessionTokenEphemeralResource) Open(ctx context.Context, req ephemeral.OpenRequest, resp *ephemeral.OpenResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var data AppSessionTokenModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, data.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	token, err := userClient(client, data.UID).CreateAppToken(ctx, data.AppID.ValueString(), timeoutOr(data.TTL, defaultAppTokenTTL))
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create app token, got error: %s", err))
+		return
+	}
+	data.Token = types.StringValue(token.Token)
+	data.ExpiresAt = stringOrNull(token.ExpiresAt)
+	resp.Diagnostics.Append(resp.Result.Set(ctx, &data)...)
+	if token.ID == "" {
+		return
+	}
+	private, err := json.Marshal(appTokenPrivate{
+		Box:   data.Box.ValueString(),
+		UID:   data.UID.ValueString(),
+		AppID: data.AppID.ValueString(),
+		ID:    token.ID,
+	})
+	if err != nil {
+		resp.Diagnostics.AddError("Internal Error", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.Private.SetKey(ctx, appTokenPrivateKey, private)...)
+}
+
+// Close revokes the token so it does not outlive the Terraform run.
+func (r *AppSessionTokenEphemeralResource) Close(ctx context.Context, req ephemeral.CloseRequest, resp *ephemeral.CloseResponse) {
+	raw, diags := req.Private.GetKey(ctx, appTokenPrivateKey)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() || raw == nil || r.client == nil {
+		return
+	}
+	var private appTokenPrivate
+	if err := json.Unmarshal(raw, &private); err != nil {
+		resp.Diagnostics.AddError("Internal Error", err.Error())
+		return
+	}
+	client := boxClient(r.client, stringOrNull(private.Box), &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	err := userClient(client, stringOrNull(private.UID)).RevokeAppToken(ctx, private.AppID, private.ID)
+	if err != nil && !errors.Is(err, errNotFound) {
+		resp.Diagnostics.AddWarning("Unable to revoke app token", fmt.Sprintf("The token for %s stays valid until it expires: %s", private.AppID, err))
+	}
+}
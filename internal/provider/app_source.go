@@ -0,0 +1,70 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework-validators/objectvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// AppSourceModel is where an app is installed from instead of lpk_url.
+type AppSourceModel struct {
+	AppStore *AppStoreSourceModel `tfsdk:"appstore"`
+}
+
+// AppStoreSourceModel installs an app from the official app store.
+type AppStoreSourceModel struct {
+	AppID   types.String `tfsdk:"appid"`
+	Channel types.String `tfsdk:"channel"`
+}
+
+func sourceAttribute() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		MarkdownDescription: "Where the application is installed from instead of `lpk_url` or `lpk_path`",
+		Optional:            true,
+		Validators: []validator.Object{
+			objectvalidator.ConflictsWith(path.MatchRoot("sha256"), path.MatchRoot("version_constraint")),
+		},
+		Attributes: map[string]schema.Attribute{
+			"appstore": schema.SingleNestedAttribute{
+				MarkdownDescription: "Install the application from the official app store. Store apps are upgraded by the store, through `auto_upgrade` or the NAS UI, so versions installed there are not drift",
+				Required:            true,
+				Attributes: map[string]schema.Attribute{
+					"appid": schema.StringAttribute{
+						MarkdownDescription: "Store ID of the application, e.g. `cloud.lazycat.app.xyz`. Changing it replaces the application",
+						Required:            true,
+						PlanModifiers: []planmodifier.String{
+							stringplanmodifier.RequiresReplace(),
+						},
+						Validators: []validator.String{
+							stringvalidator.LengthAtLeast(1),
+						},
+					},
+					"channel": schema.StringAttribute{
+						MarkdownDescription: "Release channel installed from, e.g. `stable` or `beta`. Defaults to the store default. Changing it reinstalls the application according to `upgrade_strategy`",
+						Optional:            true,
+					},
+				},
+			},
+		},
+	}
+}
+
+// fromAppStore reports whether data installs from the app store.
+func fromAppStore(data *LpkResourceModel) bool {
+	return data.Source != nil && data.Source.AppStore != nil
+}
+
+// sameSource reports whether plan installs from the same source as state.
+func sameSource(plan, state *AppSourceModel) bool {
+	if plan == nil || state == nil || plan.AppStore == nil || state.AppStore == nil {
+		return (plan == nil || plan.AppStore == nil) == (state == nil || state.AppStore == nil)
+	}
+	return plan.AppStore.AppID.Equal(state.AppStore.AppID) && plan.AppStore.Channel.Equal(state.AppStore.Channel)
+}
@@ -0,0 +1,124 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &AppStoreCategoriesDataSource{}
+
+type AppStoreCategoriesDataSource struct {
+	client Client
+}
+
+type AppStoreCategoriesDataSourceModel struct {
+	ID         types.String         `tfsdk:"id"`
+	Categories []StoreCategoryModel `tfsdk:"categories"`
+	AppIDs     []string             `tfsdk:"appids"`
+}
+
+type StoreCategoryModel struct {
+	ID     types.String `tfsdk:"id"`
+	Name   types.String `tfsdk:"name"`
+	Tags   []string     `tfsdk:"tags"`
+	AppIDs []string     `tfsdk:"appids"`
+}
+
+func NewAppStoreCategoriesDataSource() datasource.DataSource {
+	return &AppStoreCategoriesDataSource{}
+}
+
+func (d *AppStoreCategoriesDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_app_store_categories"
+}
+
+func (d *AppStoreCategoriesDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists the app store category and tag taxonomy along with the appids in each category.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Static identifier for the store catalog.",
+			},
+			"categories": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "Store categories sorted by id.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id":     schema.StringAttribute{Computed: true},
+						"name":   schema.StringAttribute{Computed: true},
+						"tags":   schema.ListAttribute{Computed: true, ElementType: types.StringType},
+						"appids": schema.ListAttribute{Computed: true, ElementType: types.StringType, Description: "Store appids listed in the category."},
+					},
+				},
+			},
+			"appids": schema.ListAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "Sorted, de-duplicated appids across all categories, useful for validating store appids.",
+			},
+		},
+	}
+}
+
+func (d *AppStoreCategoriesDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	client, ok := req.ProviderData.(Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected Client, got %T", req.ProviderData))
+		return
+	}
+	d.client = client
+}
+
+func (d *AppStoreCategoriesDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var data AppStoreCategoriesDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	categories, err := d.client.ListStoreCategories(ctx)
+	if err != nil {
+		resp.Diagnostics.AddError("List categories error", err.Error())
+		return
+	}
+	sort.Slice(categories, func(i, j int) bool { return categories[i].ID < categories[j].ID })
+	seen := make(map[string]struct{})
+	data.Categories = make([]StoreCategoryModel, 0, len(categories))
+	data.AppIDs = []string{}
+	for _, category := range categories {
+		tags := append([]string{}, category.Tags...)
+		appids := append([]string{}, category.AppIDs...)
+		sort.Strings(tags)
+		sort.Strings(appids)
+		data.Categories = append(data.Categories, StoreCategoryModel{
+			ID:     types.StringValue(category.ID),
+			Name:   types.StringValue(category.Name),
+			Tags:   tags,
+			AppIDs: appids,
+		})
+		for _, appid := range appids {
+			if _, ok := seen[appid]; ok {
+				continue
+			}
+			seen[appid] = struct{}{}
+			data.AppIDs = append(data.AppIDs, appid)
+		}
+	}
+	sort.Strings(data.AppIDs)
+	data.ID = types.StringValue("app_store_categories")
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+const (
+	tlsWaitTimeout  = 10 * time.Minute
+	tlsWaitInterval = 10 * time.Second
+)
+
+// waitForTLS polls the HTTPS endpoint of an app until its certificate
+// validates against the system roots for the endpoint host, or gives up
+// after tlsWaitTimeout. Certificates for new domains are issued after the
+// install, so the first handshakes fail or present a placeholder.
+func waitForTLS(ctx context.Context, endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+		return fmt.Errorf("the application has no HTTPS endpoint to wait for, got %q", endpoint)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+	address := net.JoinHostPort(u.Hostname(), port)
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: tlsWaitInterval},
+		Config:    &tls.Config{MinVersion: tls.VersionTLS12, ServerName: u.Hostname()},
+	}
+	ctx, cancel := context.WithTimeout(ctx, tlsWaitTimeout)
+	defer cancel()
+	for {
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err == nil {
+			return conn.Close()
+		}
+		tflog.Debug(ctx, "waiting for a valid certificate", map[string]any{
+			"address": address,
+			"last":    err.Error(),
+		})
+		if sleepErr := sleepContext(ctx, tlsWaitInterval); sleepErr != nil {
+			return fmt.Errorf("%s did not present a valid certificate in time; last error: %s", address, err)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+)
+
+// appVersionPrivateKey is the private state key holding the version the NAS
+// reported when Terraform last installed the app, or when a version
+// installed outside Terraform was accepted with ignore_version_drift.
+const appVersionPrivateKey = "expected_version"
+
+// privateState is the private state of a resource request or response.
+type privateState interface {
+	GetKey(ctx context.Context, key string) ([]byte, diag.Diagnostics)
+	SetKey(ctx context.Context, key string, value []byte) diag.Diagnostics
+}
+
+// expectedVersion returns the recorded version, or "" when none was
+// recorded, e.g. for imported apps.
+func expectedVersion(ctx context.Context, private privateState) (string, diag.Diagnostics) {
+	raw, diags := private.GetKey(ctx, appVersionPrivateKey)
+	if diags.HasError() || len(raw) == 0 {
+		return "", diags
+	}
+	var version string
+	if err := json.Unmarshal(raw, &version); err != nil {
+		diags.AddError("Invalid private state", "Unable to read the recorded application version: "+err.Error())
+	}
+	return version, diags
+}
+
+// recordVersion stores version as the expected one.
+func recordVersion(ctx context.Context, private privateState, version string) diag.Diagnostics {
+	raw, err := json.Marshal(version)
+	if err != nil {
+		var diags diag.Diagnostics
+		diags.AddError("Invalid private state", err.Error())
+		return diags
+	}
+	return private.SetKey(ctx, appVersionPrivateKey, raw)
+}
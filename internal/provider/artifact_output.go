@@ -0,0 +1,144 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+const defaultArtifactNameTemplate = "{{.Name}}-{{.Version}}-{{.SHA}}"
+
+// artifactNameFields are the placeholders of build.artifact_name_template.
+type artifactNameFields struct {
+	Name    string
+	Version string
+	SHA     string
+}
+
+// artifactLocation returns where the artifact built from workdir is kept:
+// build.output_dir, resolved against the Terraform working directory, or
+// workdir itself, under the name rendered from build.artifact_name_template.
+// The .lpk extension is added when the template leaves it out.
+func artifactLocation(workdir string, build *LPKBuildBuildModel, fields artifactNameFields) (string, error) {
+	dir := workdir
+	if build != nil && build.OutputDir.ValueString() != "" {
+		abs, err := filepath.Abs(build.OutputDir.ValueString())
+		if err != nil {
+			return "", fmt.Errorf("resolve output_dir: %w", err)
+		}
+		if err := os.MkdirAll(abs, 0o755); err != nil {
+			return "", fmt.Errorf("create output_dir: %w", err)
+		}
+		dir = abs
+	}
+	text := defaultArtifactNameTemplate
+	if build != nil && build.NameTemplate.ValueString() != "" {
+		text = build.NameTemplate.ValueString()
+	}
+	name, err := renderArtifactName(text, fields)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+func renderArtifactName(text string, fields artifactNameFields) (string, error) {
+	tmpl, err := template.New("artifact_name_template").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse artifact_name_template: %w", err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, fields); err != nil {
+		return "", fmt.Errorf("render artifact_name_template: %w", err)
+	}
+	name := strings.TrimSpace(b.String())
+	if !strings.HasSuffix(name, ".lpk") {
+		name += ".lpk"
+	}
+	if name == ".lpk" || strings.ContainsAny(name, `/\`) || name == ".." {
+		return "", fmt.Errorf("artifact_name_template must render a file name, got %q", name)
+	}
+	return name, nil
+}
+
+// moveArtifact moves src to dst, copying when they are on different file
+// systems, e.g. from a temporary clone to a dist directory.
+func moveArtifact(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp := dst + ".partial"
+	out, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	return os.Remove(src)
+}
+
+// outputChanges lists the build attributes that move the artifact between
+// state and plan.
+func outputChanges(plan, state *LPKBuildModel) []string {
+	var was, now LPKBuildBuildModel
+	if state.Build != nil {
+		was = *state.Build
+	}
+	if plan.Build != nil {
+		now = *plan.Build
+	}
+	var fields []string
+	if !now.OutputDir.IsUnknown() && now.OutputDir.ValueString() != was.OutputDir.ValueString() {
+		fields = append(fields, "build.output_dir")
+	}
+	if !now.NameTemplate.IsUnknown() && now.NameTemplate.ValueString() != was.NameTemplate.ValueString() {
+		fields = append(fields, "build.artifact_name_template")
+	}
+	return fields
+}
+
+// artifactNameTemplateValidator rejects templates that do not parse or do
+// not render a plain file name from sample values.
+type artifactNameTemplateValidator struct{}
+
+func (v artifactNameTemplateValidator) Description(_ context.Context) string {
+	return "value must be a template rendering a file name"
+}
+
+func (v artifactNameTemplateValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v artifactNameTemplateValidator) ValidateString(_ context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	_, err := renderArtifactName(req.ConfigValue.ValueString(), artifactNameFields{Name: "app", Version: "1.0.0", SHA: "0"})
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid artifact name template", err.Error())
+	}
+}
@@ -0,0 +1,102 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// LPKBuildRetentionModel bounds the artifacts kept next to the build output.
+type LPKBuildRetentionModel struct {
+	MaxArtifacts types.Int64  `tfsdk:"max_artifacts"`
+	MaxAge       types.String `tfsdk:"max_age"`
+}
+
+// artifactRetention is the parsed form of LPKBuildRetentionModel. Zero values
+// disable the corresponding limit.
+type artifactRetention struct {
+	maxArtifacts int
+	maxAge       time.Duration
+}
+
+func parseRetention(model *LPKBuildRetentionModel) (artifactRetention, error) {
+	var retention artifactRetention
+	if model == nil {
+		return retention, nil
+	}
+	if !model.MaxArtifacts.IsNull() && !model.MaxArtifacts.IsUnknown() {
+		if model.MaxArtifacts.ValueInt64() < 1 {
+			return retention, errors.New("output_retention.max_artifacts must be at least 1")
+		}
+		retention.maxArtifacts = int(model.MaxArtifacts.ValueInt64())
+	}
+	if !model.MaxAge.IsNull() && !model.MaxAge.IsUnknown() && model.MaxAge.ValueString() != "" {
+		d, err := time.ParseDuration(model.MaxAge.ValueString())
+		if err != nil || d <= 0 {
+			return retention, fmt.Errorf("output_retention.max_age must be a positive duration such as 168h, got %q", model.MaxAge.ValueString())
+		}
+		retention.maxAge = d
+	}
+	return retention, nil
+}
+
+// pruneArtifacts removes artifacts in the directory of current that exceed the
+// retention limits, newest first, never removing current itself. Only
+// artifacts the provider built, recognized by their checksum sidecar, are
+// considered. It returns the removed paths.
+func pruneArtifacts(current string, retention artifactRetention, now time.Time) ([]string, error) {
+	if retention.maxArtifacts == 0 && retention.maxAge == 0 {
+		return nil, nil
+	}
+	sidecars, err := filepath.Glob(filepath.Join(filepath.Dir(current), "*.lpk"+checksumManifestSuffix))
+	if err != nil {
+		return nil, err
+	}
+	type artifact struct {
+		path    string
+		modTime time.Time
+	}
+	var artifacts []artifact
+	for _, sidecar := range sidecars {
+		path := strings.TrimSuffix(sidecar, checksumManifestSuffix)
+		if path == current {
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		artifacts = append(artifacts, artifact{path: path, modTime: info.ModTime()})
+	}
+	sort.Slice(artifacts, func(i, j int) bool {
+		if !artifacts[i].modTime.Equal(artifacts[j].modTime) {
+			return artifacts[i].modTime.After(artifacts[j].modTime)
+		}
+		return artifacts[i].path < artifacts[j].path
+	})
+	var removed []string
+	for i, candidate := range artifacts {
+		// current counts towards max_artifacts, hence i+1.
+		overCount := retention.maxArtifacts > 0 && i+1 >= retention.maxArtifacts
+		tooOld := retention.maxAge > 0 && now.Sub(candidate.modTime) > retention.maxAge
+		if !overCount && !tooOld {
+			continue
+		}
+		if err := os.Remove(candidate.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		if err := os.Remove(candidate.path + checksumManifestSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return removed, err
+		}
+		removed = append(removed, candidate.path)
+	}
+	return removed, nil
+}
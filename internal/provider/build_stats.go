@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// LPKBuildStatsModel records how long each stage of the last apply took.
+type LPKBuildStatsModel struct {
+	CloneMs       types.Int64 `tfsdk:"clone_ms"`
+	RenderMs      types.Int64 `tfsdk:"render_ms"`
+	BuildMs       types.Int64 `tfsdk:"build_ms"`
+	UploadMs      types.Int64 `tfsdk:"upload_ms"`
+	CacheHit      types.Bool  `tfsdk:"cache_hit"`
+	ArtifactBytes types.Int64 `tfsdk:"artifact_bytes"`
+}
+
+var buildStatsAttrTypes = map[string]attr.Type{
+	"clone_ms":       types.Int64Type,
+	"render_ms":      types.Int64Type,
+	"build_ms":       types.Int64Type,
+	"upload_ms":      types.Int64Type,
+	"cache_hit":      types.BoolType,
+	"artifact_bytes": types.Int64Type,
+}
+
+func buildStatsAttribute() schema.SingleNestedAttribute {
+	return schema.SingleNestedAttribute{
+		Computed:    true,
+		Description: "Timing and cache telemetry of the last apply, for tracking build performance over time.",
+		Attributes: map[string]schema.Attribute{
+			"clone_ms": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Milliseconds spent materializing the source, e.g. cloning or extracting it.",
+			},
+			"render_ms": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Milliseconds spent checksumming the source and rendering templates.",
+			},
+			"build_ms": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Milliseconds spent building the artifact, or checking the cached one.",
+			},
+			"upload_ms": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Milliseconds spent uploading the artifact. Zero when nothing was uploaded.",
+			},
+			"cache_hit": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether an existing artifact matching the source was reused instead of running the build command.",
+			},
+			"artifact_bytes": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Size of the artifact in bytes.",
+			},
+		},
+	}
+}
+
+// buildStats accumulates stage timings during an apply.
+type buildStats struct {
+	clone, render, build, upload time.Duration
+	cacheHit                     bool
+	artifactBytes                int64
+}
+
+// since returns the time elapsed since start and resets start to now, so
+// consecutive stages can be timed with one variable.
+func since(start *time.Time) time.Duration {
+	now := time.Now()
+	elapsed := now.Sub(*start)
+	*start = now
+	return elapsed
+}
+
+func (s buildStats) object(ctx context.Context) (types.Object, diag.Diagnostics) {
+	return types.ObjectValueFrom(ctx, buildStatsAttrTypes, LPKBuildStatsModel{
+		CloneMs:       types.Int64Value(s.clone.Milliseconds()),
+		RenderMs:      types.Int64Value(s.render.Milliseconds()),
+		BuildMs:       types.Int64Value(s.build.Milliseconds()),
+		UploadMs:      types.Int64Value(s.upload.Milliseconds()),
+		CacheHit:      types.BoolValue(s.cacheHit),
+		ArtifactBytes: types.Int64Value(s.artifactBytes),
+	})
+}
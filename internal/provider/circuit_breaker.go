@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// breakerThreshold is the number of consecutive connection failures
+	// after which the breaker opens.
+	breakerThreshold = 3
+	// breakerBaseDelay and breakerMaxDelay bound the backoff while open.
+	breakerBaseDelay = 2 * time.Second
+	breakerMaxDelay  = time.Minute
+	// breakerMaxOutage is how long requests keep waiting for the NAS to come
+	// back before they fail fast.
+	breakerMaxOutage = 5 * time.Minute
+)
+
+// circuitBreaker is shared by every copy of a client so that, while the NAS
+// is unreachable, resources wait for it together instead of each retrying on
+// their own, and give up with the same error once the outage lasts too long.
+type circuitBreaker struct {
+	endpoint string
+
+	mu        sync.Mutex
+	failures  int
+	since     time.Time
+	openUntil time.Time
+	lastErr   error
+}
+
+// endpointUnavailableError is returned for every request once the breaker has
+// given up, so all resources report one identical diagnostic.
+type endpointUnavailableError struct {
+	endpoint  string
+	failures  int
+	since     time.Time
+	nextRetry time.Time
+	lastErr   error
+}
+
+func (e *endpointUnavailableError) Error() string {
+	return fmt.Sprintf("endpoint %s unavailable: %d consecutive connection failures since %s, next retry after %s; last error: %s",
+		e.endpoint, e.failures, e.since.UTC().Format(time.RFC3339), e.nextRetry.UTC().Format(time.RFC3339), e.lastErr)
+}
+
+func (e *endpointUnavailableError) Unwrap() error {
+	return e.lastErr
+}
+
+// wait blocks while the breaker is open. Once the outage has lasted longer
+// than breakerMaxOutage it returns an endpointUnavailableError instead, until
+// the backoff elapses and a request is let through to probe the NAS again.
+func (b *circuitBreaker) wait(ctx context.Context) error {
+	b.mu.Lock()
+	now := time.Now()
+	if !now.Before(b.openUntil) {
+		b.mu.Unlock()
+		return nil
+	}
+	if now.Sub(b.since) >= breakerMaxOutage {
+		err := &endpointUnavailableError{
+			endpoint:  b.endpoint,
+			failures:  b.failures,
+			since:     b.since,
+			nextRetry: b.openUntil,
+			lastErr:   b.lastErr,
+		}
+		b.mu.Unlock()
+		return err
+	}
+	delay := b.openUntil.Sub(now)
+	b.mu.Unlock()
+	return sleepContext(ctx, delay)
+}
+
+// record updates the breaker with the outcome of a request. Only connection
+// failures count; any HTTP response means the NAS is reachable.
+func (b *circuitBreaker) record(connErr error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if connErr == nil {
+		b.failures = 0
+		b.openUntil = time.Time{}
+		b.lastErr = nil
+		return
+	}
+	now := time.Now()
+	if b.failures == 0 {
+		b.since = now
+	}
+	b.failures++
+	b.lastErr = connErr
+	if b.failures < breakerThreshold {
+		return
+	}
+	delay := breakerBaseDelay
+	for i := breakerThreshold; i < b.failures && delay < breakerMaxDelay; i++ {
+		delay *= 2
+	}
+	b.openUntil = now.Add(min(delay, breakerMaxDelay))
+}
@@ -0,0 +1,236 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// AppAPI manages applications installed on the NAS.
+type AppAPI interface {
+	InstallApp(ctx context.Context, payload apiInstallRequest) (*apiAppInfo, error)
+	UpgradeApp(ctx context.Context, appID string, payload apiInstallRequest) (*apiAppInfo, error)
+	StageApp(ctx context.Context, payload apiInstallRequest) (*apiAppInfo, error)
+	PromoteApp(ctx context.Context, appID, replaces string) (*apiAppInfo, error)
+	GetApp(ctx context.Context, appID string) (*apiAppInfo, error)
+	DeleteApp(ctx context.Context, appID string, clearData bool) error
+	SetStartPriority(ctx context.Context, appID string, priority int64) error
+	SetAutoStart(ctx context.Context, appID string, autoStart bool) error
+	SetDisplayTitle(ctx context.Context, appID, title string) error
+	SetAppState(ctx context.Context, appID, state string) error
+	SetAppLabels(ctx context.Context, appID string, labels map[string]string) error
+	SetAppConfig(ctx context.Context, appID string, config apiAppConfig) error
+	SetAppRoute(ctx context.Context, appID, subdomain, customDomain string) (*apiAppInfo, error)
+	GetAppAccess(ctx context.Context, appID string) (*apiAppAccess, error)
+	SetAppAccess(ctx context.Context, appID string, access apiAppAccess) (*apiAppAccess, error)
+	GetAppResources(ctx context.Context, appID string) (*apiAppResources, error)
+	SetAppResources(ctx context.Context, appID string, limits apiAppResources) (*apiAppResources, error)
+	GetAutoUpgrade(ctx context.Context, appID string) (*apiAutoUpgrade, error)
+	SetAutoUpgrade(ctx context.Context, appID string, policy apiAutoUpgrade) (*apiAutoUpgrade, error)
+	GetAppManifest(ctx context.Context, appID string) ([]byte, error)
+	GetAppUsage(ctx context.Context, appID string) (*apiAppUsage, error)
+	ListAppEndpoints(ctx context.Context, appID string) ([]apiAppEndpoint, error)
+	ExecApp(ctx context.Context, appID string, exec apiExecRequest) (*apiExecResult, error)
+	SnapshotApp(ctx context.Context, appID string) (*apiAppSnapshot, error)
+	ProbeApp(ctx context.Context, appID, probePath string, port int64) (*apiHealthProbe, error)
+	CreateAppToken(ctx context.Context, appID string, ttl time.Duration) (*apiAppToken, error)
+	RevokeAppToken(ctx context.Context, appID, tokenID string) error
+}
+
+// RegistryAPI publishes LPK packages and browses the app store.
+type RegistryAPI interface {
+	UploadLPK(ctx context.Context, upload apiUploadRequest, filePath string) (*apiUploadLPKResponse, error)
+	UploadLPKDelta(ctx context.Context, upload apiUploadRequest, baseID, baseSHA, targetSHA string, delta []byte) (*apiUploadLPKResponse, error)
+	GetLPK(ctx context.Context, id string) (*apiUploadLPKResponse, error)
+	DeleteLPK(ctx context.Context, id string) error
+	PackageSHA256(ctx context.Context, lpkURL string) (string, error)
+	ListPackageVersions(ctx context.Context, appID string) ([]apiPackageVersion, error)
+	ListStoreCategories(ctx context.Context) ([]apiStoreCategory, error)
+}
+
+// FileAPI reads files from the NAS filesystem.
+type FileAPI interface {
+	FetchFile(ctx context.Context, path string) (*apiFileResponse, error)
+	StatFile(ctx context.Context, path string) (*apiFileStat, error)
+	FetchFileToPath(ctx context.Context, remotePath, localPath string) (*apiFileDownload, error)
+}
+
+// SystemAPI covers box-wide users, events, quotas, share permissions, and
+// maintenance windows.
+type SystemAPI interface {
+	ListUsers(ctx context.Context) ([]apiUser, error)
+	ListEvents(ctx context.Context, filter apiEventFilter) ([]apiEvent, error)
+	SetQuota(ctx context.Context, quota apiQuota) (*apiQuota, error)
+	GetQuota(ctx context.Context, id string) (*apiQuota, error)
+	DeleteQuota(ctx context.Context, id string) error
+	SetGroupSharePermission(ctx context.Context, grant apiGroupSharePermission) (*apiGroupSharePermission, error)
+	GetGroupSharePermission(ctx context.Context, share, group string) (*apiGroupSharePermission, error)
+	DeleteGroupSharePermission(ctx context.Context, share, group string) error
+	ListMaintenanceWindows(ctx context.Context) ([]apiMaintenanceWindow, error)
+	SetMaintenanceWindow(ctx context.Context, window apiMaintenanceWindow) (*apiMaintenanceWindow, error)
+	GetMaintenanceWindow(ctx context.Context, id string) (*apiMaintenanceWindow, error)
+	DeleteMaintenanceWindow(ctx context.Context, id string) error
+}
+
+// Client is what the provider hands to resources, data sources, and
+// functions. LcmdClient implements it over the REST API; other transports
+// or test doubles only need to satisfy this interface.
+type Client interface {
+	AppAPI
+	RegistryAPI
+	FileAPI
+	SystemAPI
+	// UID returns the NAS user resources act on.
+	UID() string
+	// ForUser returns a client that acts on behalf of uid instead.
+	ForUser(uid string) Client
+	// Box returns the client for a named endpoint of the provider `boxes`
+	// setting.
+	Box(name string) (Client, error)
+	// ForRegistry returns a client that uploads to and deletes from the
+	// registry at registryURL instead of the NAS endpoint.
+	ForRegistry(registryURL string) (Client, error)
+	// PublishDefaults returns the provider `default_publish` settings.
+	PublishDefaults() publishDefaults
+	// DefaultLabels returns the provider `default_labels`.
+	DefaultLabels() map[string]string
+	// AwaitTeardown blocks until every app uninstall with a lower teardown
+	// priority in progress through this provider has finished. The returned
+	// function must be called once the caller's uninstall is done.
+	AwaitTeardown(ctx context.Context, priority int64) (func(), error)
+	// IsReadOnly reports whether mutating operations must be rejected.
+	IsReadOnly() bool
+	// MaintenanceWindows returns the windows that gate mutating operations.
+	MaintenanceWindows(ctx context.Context) ([]apiMaintenanceWindow, error)
+}
+
+var _ Client = &LcmdClient{}
+
+func (c *LcmdClient) UID() string {
+	return c.User
+}
+
+func (c *LcmdClient) ForUser(uid string) Client {
+	scoped := *c
+	scoped.User = uid
+	return &scoped
+}
+
+func (c *LcmdClient) Box(name string) (Client, error) {
+	box, ok := c.boxes[name]
+	if !ok {
+		names := slices.Sorted(maps.Keys(c.boxes))
+		if len(names) == 0 {
+			return nil, fmt.Errorf("box %q is not configured; the provider has no boxes", name)
+		}
+		return nil, fmt.Errorf("box %q is not configured; configured boxes: %s", name, strings.Join(names, ", "))
+	}
+	return box, nil
+}
+
+func (c *LcmdClient) ForRegistry(registryURL string) (Client, error) {
+	if err := validateRegistryURL(registryURL); err != nil {
+		return nil, err
+	}
+	parsed, err := url.Parse(registryURL)
+	if err != nil {
+		return nil, err
+	}
+	// The registry is a separate server, so it gets its own version
+	// negotiation state and circuit breaker.
+	scoped := *c
+	scoped.baseURL = parsed
+	scoped.session = &clientSession{}
+	scoped.breaker = &circuitBreaker{endpoint: registryURL}
+	return &scoped, nil
+}
+
+func (c *LcmdClient) PublishDefaults() publishDefaults {
+	return c.publish
+}
+
+func (c *LcmdClient) DefaultLabels() map[string]string {
+	return c.labels
+}
+
+func (c *LcmdClient) AwaitTeardown(ctx context.Context, priority int64) (func(), error) {
+	return c.teardown.acquire(ctx, priority)
+}
+
+func (c *LcmdClient) IsReadOnly() bool {
+	return c.ReadOnly
+}
+
+func (c *LcmdClient) MaintenanceWindows(ctx context.Context) ([]apiMaintenanceWindow, error) {
+	if err := c.ready(ctx); err != nil {
+		return nil, err
+	}
+	c.session.mu.Lock()
+	defer c.session.mu.Unlock()
+	return c.session.windows, nil
+}
+
+// boxClient returns the client for box, or client itself when box is not set
+// so the provider endpoint applies. An unknown box is reported in diags.
+func boxClient(client Client, box types.String, diags *diag.Diagnostics) Client {
+	if client == nil || box.IsNull() || box.IsUnknown() || box.ValueString() == "" {
+		return client
+	}
+	scoped, err := client.Box(box.ValueString())
+	if err != nil {
+		diags.AddError("Invalid box", err.Error())
+		return nil
+	}
+	return scoped
+}
+
+// boxAttribute is the `box` attribute shared by every resource. Moving a
+// resource to another box replaces it.
+func boxAttribute() schema.StringAttribute {
+	return schema.StringAttribute{
+		MarkdownDescription: "Name of the entry in the provider `boxes` setting to manage this resource on. Defaults to the provider `endpoint`; changing it recreates the resource on the new box.",
+		Optional:            true,
+		PlanModifiers: []planmodifier.String{
+			stringplanmodifier.RequiresReplace(),
+		},
+	}
+}
+
+// userClient returns client scoped to uid, or client itself when uid is not
+// set so the provider-level user applies.
+func userClient(client Client, uid types.String) Client {
+	if uid.IsNull() || uid.IsUnknown() || uid.ValueString() == "" {
+		return client
+	}
+	return client.ForUser(uid.ValueString())
+}
+
+// importStateWithBox imports an ID of the form `<box>/<id>` into the box and
+// id attributes, or a plain ID into id for the provider endpoint.
+func importStateWithBox(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	box, id, ok := strings.Cut(req.ID, "/")
+	if !ok {
+		resource.ImportStatePassthroughID(ctx, path.Root("id"), req, resp)
+		return
+	}
+	if box == "" || id == "" {
+		resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("Expected <id> or <box>/<id>, got %q", req.ID))
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("box"), box)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), id)...)
+}
@@ -0,0 +1,100 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// CredentialsSourceModel selects where the API token is read from.
+type CredentialsSourceModel struct {
+	File    types.String `tfsdk:"file"`
+	Env     types.String `tfsdk:"env"`
+	Command types.String `tfsdk:"command"`
+}
+
+// resolveCredential reads the API token from the configured source. The
+// token is never written to state; surrounding whitespace is trimmed.
+func resolveCredential(ctx context.Context, source *CredentialsSourceModel) (string, error) {
+	var token string
+	switch {
+	case source.File.ValueString() != "":
+		contents, err := os.ReadFile(source.File.ValueString())
+		if err != nil {
+			return "", fmt.Errorf("read credentials file: %w", err)
+		}
+		token = string(contents)
+	case source.Env.ValueString() != "":
+		value, ok := os.LookupEnv(source.Env.ValueString())
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", source.Env.ValueString())
+		}
+		token = value
+	case source.Command.ValueString() != "":
+		cmd := exec.CommandContext(ctx, "sh", "-c", source.Command.ValueString())
+		var stdout, stderr bytes.Buffer
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return "", fmt.Errorf("credentials command failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+		}
+		token = stdout.String()
+	default:
+		return "", errors.New("one of file, env, or command must be set")
+	}
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return "", errors.New("credentials source returned an empty token")
+	}
+	return token, nil
+}
+
+// credentialHelperToken asks a git style credential helper for the token of
+// endpoint. helper is run as `<helper> get`; when it is a bare name and
+// git-credential-<helper> is on PATH, that program is used instead.
+func credentialHelperToken(ctx context.Context, helper string, endpoint *url.URL, user string) (string, error) {
+	fields := strings.Fields(helper)
+	if len(fields) == 0 {
+		return "", errors.New("credential_helper is empty")
+	}
+	program := fields[0]
+	if !strings.ContainsRune(program, filepath.Separator) {
+		if prefixed, err := exec.LookPath("git-credential-" + program); err == nil {
+			program = prefixed
+		}
+	}
+	args := append(fields[1:], "get")
+	cmd := exec.CommandContext(ctx, program, args...)
+	var stdin bytes.Buffer
+	fmt.Fprintf(&stdin, "protocol=%s\nhost=%s\n", endpoint.Scheme, endpoint.Host)
+	if p := strings.TrimPrefix(endpoint.Path, "/"); p != "" {
+		fmt.Fprintf(&stdin, "path=%s\n", p)
+	}
+	if user != "" {
+		fmt.Fprintf(&stdin, "username=%s\n", user)
+	}
+	stdin.WriteString("\n")
+	cmd.Stdin = &stdin
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s get: %w: %s", program, err, strings.TrimSpace(stderr.String()))
+	}
+	for _, line := range strings.Split(stdout.String(), "\n") {
+		if password, ok := strings.CutPrefix(strings.TrimRight(line, "\r"), "password="); ok && password != "" {
+			return password, nil
+		}
+	}
+	return "", fmt.Errorf("%s get returned no password", program)
+}
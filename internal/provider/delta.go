@@ -0,0 +1,134 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+)
+
+// deltaFormat identifies the encoding produced by computeDelta. A delta is the
+// magic header followed by a sequence of operations:
+//
+//	'C' uvarint(offset) uvarint(length)  copy length bytes of the base at offset
+//	'D' uvarint(length) bytes            insert literal bytes
+const deltaFormat = "lcmd-block-delta-v1"
+
+var deltaMagic = []byte("LCMDDELTA1")
+
+const deltaBlockSize = 4096
+
+// computeDelta encodes target as copies from base plus literal data, using an
+// rsync-style rolling checksum so matches are found at any offset.
+func computeDelta(base, target []byte) []byte {
+	blocks := make(map[uint32][]int)
+	for offset := 0; offset+deltaBlockSize <= len(base); offset += deltaBlockSize {
+		weak := weakChecksum(base[offset : offset+deltaBlockSize])
+		blocks[weak] = append(blocks[weak], offset)
+	}
+	enc := deltaEncoder{}
+	enc.buf.Write(deltaMagic)
+	literalStart := 0
+	pos := 0
+	var a, b uint32
+	rolling := false
+	for pos+deltaBlockSize <= len(target) {
+		window := target[pos : pos+deltaBlockSize]
+		if !rolling {
+			a, b = weakParts(window)
+			rolling = true
+		}
+		if offset, ok := matchBlock(blocks, base, window, a|b<<16); ok {
+			enc.literal(target[literalStart:pos])
+			enc.copy(offset, deltaBlockSize)
+			pos += deltaBlockSize
+			literalStart = pos
+			rolling = false
+			continue
+		}
+		if pos+deltaBlockSize < len(target) {
+			out := uint32(target[pos])
+			in := uint32(target[pos+deltaBlockSize])
+			a = (a - out + in) & 0xffff
+			b = (b - deltaBlockSize*out + a) & 0xffff
+		}
+		pos++
+	}
+	enc.literal(target[literalStart:])
+	enc.flushCopy()
+	return enc.buf.Bytes()
+}
+
+func matchBlock(blocks map[uint32][]int, base, window []byte, weak uint32) (int, bool) {
+	candidates, ok := blocks[weak]
+	if !ok {
+		return 0, false
+	}
+	strong := sha256.Sum256(window)
+	for _, offset := range candidates {
+		if sha256.Sum256(base[offset:offset+deltaBlockSize]) == strong {
+			return offset, true
+		}
+	}
+	return 0, false
+}
+
+func weakParts(data []byte) (uint32, uint32) {
+	var a, b uint32
+	n := uint32(len(data))
+	for i, c := range data {
+		a += uint32(c)
+		b += (n - uint32(i)) * uint32(c)
+	}
+	return a & 0xffff, b & 0xffff
+}
+
+func weakChecksum(data []byte) uint32 {
+	a, b := weakParts(data)
+	return a | b<<16
+}
+
+type deltaEncoder struct {
+	buf     bytes.Buffer
+	pending struct {
+		offset, length int
+	}
+}
+
+func (e *deltaEncoder) copy(offset, length int) {
+	// Merge adjacent copies to keep the delta compact.
+	if e.pending.length > 0 && e.pending.offset+e.pending.length == offset {
+		e.pending.length += length
+		return
+	}
+	e.flushCopy()
+	e.pending.offset = offset
+	e.pending.length = length
+}
+
+func (e *deltaEncoder) literal(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	e.flushCopy()
+	e.buf.WriteByte('D')
+	e.uvarint(len(data))
+	e.buf.Write(data)
+}
+
+func (e *deltaEncoder) flushCopy() {
+	if e.pending.length == 0 {
+		return
+	}
+	e.buf.WriteByte('C')
+	e.uvarint(e.pending.offset)
+	e.uvarint(e.pending.length)
+	e.pending.length = 0
+}
+
+func (e *deltaEncoder) uvarint(v int) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], uint64(v))
+	e.buf.Write(tmp[:n])
+}
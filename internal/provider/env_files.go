@@ -0,0 +1,107 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// loadEnvFiles reads the env.files dotenv files of env, relative to the
+// source directory dir, in order, so later files override earlier ones.
+func loadEnvFiles(ctx context.Context, dir string, env *LPKBuildEnvModel) (map[string]string, error) {
+	if env == nil || env.Files.IsNull() || env.Files.IsUnknown() {
+		return nil, nil
+	}
+	var files []string
+	if diags := env.Files.ElementsAs(ctx, &files, false); diags.HasError() {
+		return nil, errors.New("env.files must be a list of strings")
+	}
+	values := make(map[string]string)
+	for _, file := range files {
+		if err := checkRelativePath(file); err != nil {
+			return nil, fmt.Errorf("env.files: %w", err)
+		}
+		path, err := resolveWithin(dir, filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			return nil, fmt.Errorf("env file %s: %w", file, err)
+		}
+		if err := parseDotenv(path, values); err != nil {
+			return nil, fmt.Errorf("env file %s: %w", file, err)
+		}
+	}
+	return values, nil
+}
+
+// parseDotenv adds the KEY=value lines of the dotenv file at path to values.
+// Blank lines and # comments are skipped and an export prefix is allowed.
+// Double-quoted values expand \n, \t, \", and \; single-quoted values are
+// literal; unquoted values end at a " #" comment and are trimmed.
+func parseDotenv(path string, values map[string]string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, raw, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return fmt.Errorf("line %d: expected KEY=value", n)
+		}
+		value, err := dotenvValue(strings.TrimSpace(raw))
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		values[key] = value
+	}
+	return scanner.Err()
+}
+
+func dotenvValue(raw string) (string, error) {
+	switch {
+	case strings.HasPrefix(raw, `"`):
+		var b strings.Builder
+		for i := 1; i < len(raw); i++ {
+			c := raw[i]
+			switch {
+			case c == '"':
+				return b.String(), nil
+			case c == '\\' && i+1 < len(raw):
+				i++
+				switch raw[i] {
+				case 'n':
+					b.WriteByte('\n')
+				case 't':
+					b.WriteByte('\t')
+				default:
+					b.WriteByte(raw[i])
+				}
+			default:
+				b.WriteByte(c)
+			}
+		}
+		return "", errors.New("unterminated double-quoted value")
+	case strings.HasPrefix(raw, "'"):
+		end := strings.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return "", errors.New("unterminated single-quoted value")
+		}
+		return raw[1 : end+1], nil
+	}
+	if i := strings.Index(raw, " #"); i >= 0 {
+		raw = raw[:i]
+	}
+	return strings.TrimSpace(raw), nil
+}
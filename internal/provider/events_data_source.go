@@ -0,0 +1,169 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &EventsDataSource{}
+
+type EventsDataSource struct {
+	client Client
+}
+
+type EventsDataSourceModel struct {
+	ID       types.String `tfsdk:"id"`
+	Since    types.String `tfsdk:"since"`
+	Until    types.String `tfsdk:"until"`
+	AppID    types.String `tfsdk:"appid"`
+	Severity types.String `tfsdk:"severity"`
+	Limit    types.Int64  `tfsdk:"limit"`
+	Events   []EventModel `tfsdk:"events"`
+}
+
+type EventModel struct {
+	ID        types.String `tfsdk:"id"`
+	Timestamp types.String `tfsdk:"timestamp"`
+	Type      types.String `tfsdk:"type"`
+	Severity  types.String `tfsdk:"severity"`
+	AppID     types.String `tfsdk:"appid"`
+	Message   types.String `tfsdk:"message"`
+}
+
+var eventSeverities = []string{"debug", "info", "warning", "error", "critical"}
+
+func NewEventsDataSource() datasource.DataSource {
+	return &EventsDataSource{}
+}
+
+func (d *EventsDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_events"
+}
+
+func (d *EventsDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Lists recent system and application events (installs, uninstalls, crashes, disk alerts) from the NAS event log.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Internal identifier derived from the applied filters.",
+			},
+			"since": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only return events at or after this RFC3339 timestamp.",
+			},
+			"until": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only return events before this RFC3339 timestamp.",
+			},
+			"appid": schema.StringAttribute{
+				Optional:    true,
+				Description: "Only return events emitted by this application.",
+			},
+			"severity": schema.StringAttribute{
+				Optional:    true,
+				Description: "Minimum severity to return: debug, info, warning, error, or critical.",
+				Validators: []validator.String{
+					stringvalidator.OneOf(eventSeverities...),
+				},
+			},
+			"limit": schema.Int64Attribute{
+				Optional:    true,
+				Description: "Maximum number of events to return. Defaults to the NAS API limit.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"events": schema.ListNestedAttribute{
+				Computed:    true,
+				Description: "Matching events, newest first.",
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"id":        schema.StringAttribute{Computed: true},
+						"timestamp": schema.StringAttribute{Computed: true, Description: "RFC3339 time the event was recorded."},
+						"type":      schema.StringAttribute{Computed: true, Description: "Event type, e.g. install, uninstall, crash, or disk."},
+						"severity":  schema.StringAttribute{Computed: true},
+						"appid":     schema.StringAttribute{Computed: true},
+						"message":   schema.StringAttribute{Computed: true},
+					},
+				},
+			},
+		},
+	}
+}
+
+func (d *EventsDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	client, ok := req.ProviderData.(Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected Client, got %T", req.ProviderData))
+		return
+	}
+	d.client = client
+}
+
+func (d *EventsDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var data EventsDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	filter := apiEventFilter{
+		Since:    data.Since.ValueString(),
+		Until:    data.Until.ValueString(),
+		AppID:    data.AppID.ValueString(),
+		Severity: data.Severity.ValueString(),
+		Limit:    data.Limit.ValueInt64(),
+	}
+	for name, value := range map[string]string{"since": filter.Since, "until": filter.Until} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			resp.Diagnostics.AddError("Invalid timestamp", fmt.Sprintf("%s must be an RFC3339 timestamp: %s", name, err))
+			return
+		}
+	}
+	events, err := d.client.ListEvents(ctx, filter)
+	if err != nil {
+		resp.Diagnostics.AddError("List events error", err.Error())
+		return
+	}
+	data.Events = make([]EventModel, 0, len(events))
+	for _, event := range events {
+		data.Events = append(data.Events, EventModel{
+			ID:        types.StringValue(event.ID),
+			Timestamp: types.StringValue(event.Timestamp),
+			Type:      types.StringValue(event.Type),
+			Severity:  types.StringValue(event.Severity),
+			AppID:     stringOrNull(event.AppID),
+			Message:   types.StringValue(event.Message),
+		})
+	}
+	data.ID = types.StringValue(buildEventsID(filter))
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
+
+func buildEventsID(filter apiEventFilter) string {
+	input := fmt.Sprintf("%s|%s|%s|%s|%d", filter.Since, filter.Until, filter.AppID, filter.Severity, filter.Limit)
+	sum := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(sum[:])
+}
@@ -17,7 +17,7 @@ import (
 var _ datasource.DataSource = &FileDataSource{}
 
 type FileDataSource struct {
-	client *LcmdClient
+	client Client
 }
 
 type FileDataSourceModel struct {
@@ -75,9 +75,9 @@ func (d *FileDataSource) Configure(_ context.Context, req datasource.ConfigureRe
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*LcmdClient)
+	client, ok := req.ProviderData.(Client)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected *LcmdClient, got %T", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected Client, got %T", req.ProviderData))
 		return
 	}
 	d.client = client
@@ -0,0 +1,187 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ resource.Resource = &FileDownloadResource{}
+
+type FileDownloadResource struct {
+	client Client
+}
+
+type FileDownloadModel struct {
+	ID          types.String `tfsdk:"id"`
+	Box         types.String `tfsdk:"box"`
+	Path        types.String `tfsdk:"path"`
+	Destination types.String `tfsdk:"destination"`
+	SHA256      types.String `tfsdk:"sha256"`
+	Size        types.Int64  `tfsdk:"size"`
+}
+
+func NewFileDownloadResource() resource.Resource {
+	return &FileDownloadResource{}
+}
+
+func (r *FileDownloadResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_file_download"
+}
+
+func (r *FileDownloadResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Downloads a file from the NAS filesystem to a local path. Unlike the lcmd_file data source the contents are streamed to disk rather than held in state, so large files are supported. The file is downloaded again when it changes on the NAS or the local copy is modified or removed, and is deleted locally on destroy.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Internal identifier derived from path and checksum.",
+			},
+			"box": boxAttribute(),
+			"path": schema.StringAttribute{
+				Required:    true,
+				Description: "Absolute path to the file on the NAS.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"destination": schema.StringAttribute{
+				Required:    true,
+				Description: "Local path the file is written to. Missing parent directories are created. An interrupted download leaves a .part file next to it that the next apply resumes from.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "Hex-encoded SHA256 checksum of the downloaded file, verified against the checksum reported by the NAS.",
+			},
+			"size": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Size of the downloaded file in bytes.",
+			},
+		},
+	}
+}
+
+func (r *FileDownloadResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	client, ok := req.ProviderData.(Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected Client, got %T", req.ProviderData))
+		return
+	}
+	r.client = client
+}
+
+func (r *FileDownloadResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var plan FileDownloadModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, plan.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	download, err := client.FetchFileToPath(ctx, plan.Path.ValueString(), plan.Destination.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to download %s, got error: %s", plan.Path.ValueString(), err))
+		return
+	}
+	plan.ID = types.StringValue(buildFileID(plan.Path.ValueString(), download.SHA256))
+	plan.SHA256 = types.StringValue(download.SHA256)
+	plan.Size = types.Int64Value(download.Size)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *FileDownloadResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var state FileDownloadModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, state.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	// A local copy that is gone or was modified is downloaded again.
+	local, err := computeSHA(state.Destination.ValueString())
+	if os.IsNotExist(err) || (err == nil && local != state.SHA256.ValueString()) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Read file failed", err.Error())
+		return
+	}
+	// So is a file that changed on the NAS. One that was removed there keeps
+	// its local copy until the configuration drops it.
+	stat, err := client.StatFile(ctx, state.Path.ValueString())
+	if errors.Is(err, errNotFound) {
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Stat error", err.Error())
+		return
+	}
+	if stat.SHA256 != "" && stat.SHA256 != state.SHA256.ValueString() {
+		resp.State.RemoveResource(ctx)
+	}
+}
+
+func (r *FileDownloadResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	// Every configurable attribute requires replacement, so there is nothing
+	// to change in place.
+	var plan, state FileDownloadModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.ID = state.ID
+	plan.SHA256 = state.SHA256
+	plan.Size = state.Size
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *FileDownloadResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state FileDownloadModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	destination := state.Destination.ValueString()
+	for _, p := range []string{destination, destination + ".part"} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			resp.Diagnostics.AddError("Delete file failed", err.Error())
+		}
+	}
+}
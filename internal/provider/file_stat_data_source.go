@@ -0,0 +1,130 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/datasource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ datasource.DataSource = &FileStatDataSource{}
+
+type FileStatDataSource struct {
+	client Client
+}
+
+type FileStatDataSourceModel struct {
+	ID      types.String `tfsdk:"id"`
+	Path    types.String `tfsdk:"path"`
+	Exists  types.Bool   `tfsdk:"exists"`
+	IsDir   types.Bool   `tfsdk:"is_dir"`
+	Size    types.Int64  `tfsdk:"size"`
+	Mode    types.String `tfsdk:"mode"`
+	ModTime types.String `tfsdk:"mtime"`
+	SHA256  types.String `tfsdk:"sha256"`
+}
+
+func NewFileStatDataSource() datasource.DataSource {
+	return &FileStatDataSource{}
+}
+
+func (d *FileStatDataSource) Metadata(_ context.Context, req datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_file_stat"
+}
+
+func (d *FileStatDataSource) Schema(_ context.Context, _ datasource.SchemaRequest, resp *datasource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Looks up metadata of a NAS path without transferring its contents. A missing path is not an error; exists is false instead.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Internal identifier derived from path and checksum.",
+			},
+			"path": schema.StringAttribute{
+				Required:    true,
+				Description: "Absolute path on the NAS.",
+			},
+			"exists": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether the path exists.",
+			},
+			"is_dir": schema.BoolAttribute{
+				Computed:    true,
+				Description: "Whether the path is a directory.",
+			},
+			"size": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Size in bytes.",
+			},
+			"mode": schema.StringAttribute{
+				Computed:    true,
+				Description: "Permission bits in octal, e.g. 0644.",
+			},
+			"mtime": schema.StringAttribute{
+				Computed:    true,
+				Description: "Last modification time in RFC3339 format.",
+			},
+			"sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "Hex-encoded SHA256 checksum of the file contents as computed by the NAS. Null for directories.",
+			},
+		},
+	}
+}
+
+func (d *FileStatDataSource) Configure(_ context.Context, req datasource.ConfigureRequest, resp *datasource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	client, ok := req.ProviderData.(Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Data Source Configure Type", fmt.Sprintf("Expected Client, got %T", req.ProviderData))
+		return
+	}
+	d.client = client
+}
+
+func (d *FileStatDataSource) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	if d.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var data FileStatDataSourceModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if data.Path.IsUnknown() || data.Path.IsNull() || data.Path.ValueString() == "" {
+		resp.Diagnostics.AddError("Missing path", "path must be provided")
+		return
+	}
+	stat, err := d.client.StatFile(ctx, data.Path.ValueString())
+	if errors.Is(err, errNotFound) {
+		data.ID = types.StringValue(buildFileID(data.Path.ValueString(), ""))
+		data.Exists = types.BoolValue(false)
+		data.IsDir = types.BoolValue(false)
+		data.Size = types.Int64Null()
+		data.Mode = types.StringNull()
+		data.ModTime = types.StringNull()
+		data.SHA256 = types.StringNull()
+		resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Stat error", err.Error())
+		return
+	}
+	data.ID = types.StringValue(buildFileID(data.Path.ValueString(), stat.SHA256))
+	data.Exists = types.BoolValue(true)
+	data.IsDir = types.BoolValue(stat.IsDir)
+	data.Size = types.Int64Value(stat.Size)
+	data.Mode = stringOrNull(stat.Mode)
+	data.ModTime = stringOrNull(stat.ModTime)
+	data.SHA256 = stringOrNull(stat.SHA256)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &data)...)
+}
@@ -0,0 +1,239 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ resource.Resource = &GroupSharePermissionResource{}
+var _ resource.ResourceWithImportState = &GroupSharePermissionResource{}
+
+type GroupSharePermissionResource struct {
+	client Client
+}
+
+type GroupSharePermissionModel struct {
+	ID         types.String `tfsdk:"id"`
+	Box        types.String `tfsdk:"box"`
+	Share      types.String `tfsdk:"share"`
+	Group      types.String `tfsdk:"group"`
+	Permission types.String `tfsdk:"permission"`
+}
+
+func NewGroupSharePermissionResource() resource.Resource {
+	return &GroupSharePermissionResource{}
+}
+
+func (r *GroupSharePermissionResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_group_share_permission"
+}
+
+func (r *GroupSharePermissionResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Grants a NAS group read, write, or admin permission on a share.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Identifier of the form <share>:<group>.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"box": boxAttribute(),
+			"share": schema.StringAttribute{
+				Required:    true,
+				Description: "Name of the share.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(regexp.MustCompile(`^[^:/]+$`), "must not be empty or contain a colon or slash"),
+				},
+			},
+			"group": schema.StringAttribute{
+				Required:    true,
+				Description: "Name of the group the permission is granted to.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.LengthAtLeast(1),
+				},
+			},
+			"permission": schema.StringAttribute{
+				Required:    true,
+				Description: "Permission level: read, write, or admin. write implies read, and admin also allows managing the share.",
+				Validators: []validator.String{
+					stringvalidator.OneOf("read", "write", "admin"),
+				},
+			},
+		},
+	}
+}
+
+func (r *GroupSharePermissionResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	client, ok := req.ProviderData.(Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected Client, got %T", req.ProviderData))
+		return
+	}
+	r.client = client
+}
+
+func (r *GroupSharePermissionResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var plan GroupSharePermissionModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, plan.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	grant, err := client.SetGroupSharePermission(ctx, groupSharePermissionRequest(&plan))
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to grant share permission, got error: %s", err))
+		return
+	}
+	applyGroupSharePermission(&plan, grant)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *GroupSharePermissionResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var state GroupSharePermissionModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, state.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	share, group, err := splitGroupShareID(state.ID.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid ID", err.Error())
+		return
+	}
+	grant, err := client.GetGroupSharePermission(ctx, share, group)
+	if errors.Is(err, errNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Read share permission failed", err.Error())
+		return
+	}
+	applyGroupSharePermission(&state, grant)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *GroupSharePermissionResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var plan GroupSharePermissionModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, plan.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	grant, err := client.SetGroupSharePermission(ctx, groupSharePermissionRequest(&plan))
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update share permission, got error: %s", err))
+		return
+	}
+	applyGroupSharePermission(&plan, grant)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *GroupSharePermissionResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var state GroupSharePermissionModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, state.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	err := client.DeleteGroupSharePermission(ctx, state.Share.ValueString(), state.Group.ValueString())
+	if err != nil && !errors.Is(err, errNotFound) {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to revoke share permission, got error: %s", err))
+	}
+}
+
+func (r *GroupSharePermissionResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	importStateWithBox(ctx, req, resp)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	var id string
+	resp.Diagnostics.Append(resp.State.GetAttribute(ctx, path.Root("id"), &id)...)
+	share, group, err := splitGroupShareID(id)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid import ID", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("share"), share)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("group"), group)...)
+}
+
+func splitGroupShareID(id string) (string, string, error) {
+	share, group, ok := strings.Cut(id, ":")
+	if !ok || share == "" || group == "" {
+		return "", "", fmt.Errorf("expected <share>:<group>, got %q", id)
+	}
+	return share, group, nil
+}
+
+func groupSharePermissionRequest(data *GroupSharePermissionModel) apiGroupSharePermission {
+	return apiGroupSharePermission{
+		Share:      data.Share.ValueString(),
+		Group:      data.Group.ValueString(),
+		Permission: data.Permission.ValueString(),
+	}
+}
+
+func applyGroupSharePermission(data *GroupSharePermissionModel, grant *apiGroupSharePermission) {
+	if grant.Share != "" {
+		data.Share = types.StringValue(grant.Share)
+	}
+	if grant.Group != "" {
+		data.Group = types.StringValue(grant.Group)
+	}
+	data.Permission = types.StringValue(grant.Permission)
+	data.ID = types.StringValue(data.Share.ValueString() + ":" + data.Group.ValueString())
+}
@@ -0,0 +1,175 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// debugBodyLimit bounds how much of each request and response body is logged.
+const debugBodyLimit = 2048
+
+const redacted = "REDACTED"
+
+// redactedHeaders never have their values logged.
+var redactedHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// sensitiveKeyParts mark JSON object keys whose values are redacted, matched
+// case-insensitively against any part of the key.
+var sensitiveKeyParts = []string{"token", "password", "secret", "authorization", "credential", "private_key"}
+
+// sensitiveObjectKeys name objects holding environment variables, whose values are redacted
+// wholesale because any of them may be a secret.
+var sensitiveObjectKeys = []string{"env", "environment", "variables", "services_env"}
+
+// debugTransport logs every request and response through tflog.
+type debugTransport struct {
+	next http.RoundTripper
+}
+
+func (t *debugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	fields := map[string]any{
+		"method":  req.Method,
+		"path":    req.URL.Path,
+		"query":   req.URL.Query().Encode(),
+		"headers": redactHeaders(req.Header),
+	}
+	if body := requestBody(req); body != "" {
+		fields["request_body"] = body
+	}
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	fields["duration_ms"] = time.Since(start).Milliseconds()
+	if err != nil {
+		fields["error"] = err.Error()
+		tflog.Debug(ctx, "NAS API request failed", fields)
+		return resp, err
+	}
+	fields["status"] = resp.StatusCode
+	if isJSON(resp.Header.Get("Content-Type")) {
+		data, readErr := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(bytes.NewReader(data))
+		if readErr != nil {
+			return nil, readErr
+		}
+		fields["response_body"] = redactBody(data)
+	}
+	tflog.Debug(ctx, "NAS API request", fields)
+	return resp, nil
+}
+
+func requestBody(req *http.Request) string {
+	if req.GetBody == nil || !isJSON(req.Header.Get("Content-Type")) {
+		if req.ContentLength > 0 {
+			return "<" + req.Header.Get("Content-Type") + " body omitted>"
+		}
+		return ""
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return ""
+	}
+	defer body.Close()
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return ""
+	}
+	return redactBody(data)
+}
+
+func isJSON(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/json")
+}
+
+func redactHeaders(header http.Header) map[string]string {
+	out := make(map[string]string, len(header))
+	for name := range header {
+		out[name] = header.Get(name)
+	}
+	for _, name := range redactedHeaders {
+		if _, ok := out[name]; ok {
+			out[name] = redacted
+		}
+	}
+	return out
+}
+
+// redactBody returns data with sensitive JSON values replaced, truncated to
+// debugBodyLimit. Bodies that are not JSON are only truncated.
+func redactBody(data []byte) string {
+	var doc any
+	if err := json.Unmarshal(data, &doc); err == nil {
+		if redactedDoc, err := json.Marshal(redactValue(doc)); err == nil {
+			data = redactedDoc
+		}
+	}
+	if len(data) > debugBodyLimit {
+		return string(data[:debugBodyLimit]) + "...(truncated)"
+	}
+	return string(data)
+}
+
+func redactValue(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for key, child := range val {
+			lower := strings.ToLower(key)
+			switch {
+			case containsAny(lower, sensitiveKeyParts):
+				val[key] = redacted
+			case slices.Contains(sensitiveObjectKeys, lower):
+				val[key] = redactAll(child)
+			default:
+				val[key] = redactValue(child)
+			}
+		}
+		return val
+	case []any:
+		for i, child := range val {
+			val[i] = redactValue(child)
+		}
+		return val
+	default:
+		return v
+	}
+}
+
+// redactAll replaces every scalar in v, keeping keys so the shape of
+// environment maps stays visible.
+func redactAll(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for key, child := range val {
+			val[key] = redactAll(child)
+		}
+		return val
+	case []any:
+		for i, child := range val {
+			val[i] = redactAll(child)
+		}
+		return val
+	case nil:
+		return nil
+	default:
+		return redacted
+	}
+}
+
+func containsAny(s string, parts []string) bool {
+	for _, part := range parts {
+		if strings.Contains(s, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,139 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// featureInstallTasks is the capability advertised by firmware that runs
+// installs and upgrades as tasks which can be polled for progress.
+const featureInstallTasks = "install_tasks"
+
+// featureAppInstances is the capability advertised by firmware that installs
+// a package under an appid with the suffix of apiInstallRequest.
+const featureAppInstances = "app_instances"
+
+// installPollInterval is how often a running install task is polled.
+const installPollInterval = 2 * time.Second
+
+// Phases of an install task.
+const (
+	taskPhaseDone   = "done"
+	taskPhaseFailed = "failed"
+)
+
+// apiInstallTask is the reply to an install or upgrade submitted without
+// waiting.
+type apiInstallTask struct {
+	TaskID string `json:"task_id"`
+}
+
+// apiInstallTaskStatus is the progress of an install task. Phase moves
+// through queued, downloading, unpacking, and starting to done or failed;
+// Progress is the percentage of the current phase where the NAS reports it.
+type apiInstallTaskStatus struct {
+	Phase    string      `json:"phase"`
+	Progress *int64      `json:"progress,omitempty"`
+	Message  string      `json:"message,omitempty"`
+	Error    string      `json:"error,omitempty"`
+	App      *apiAppInfo `json:"app,omitempty"`
+}
+
+// installPackage posts an install or upgrade to route. When payload.Wait is
+// set and the NAS advertises featureInstallTasks, the request does not wait;
+// instead the task it starts is followed with waitInstallTask, so only ctx,
+// typically the resource timeouts, bounds how long it may take. Firmware
+// without tasks would report the app as installed before it is, so the
+// feature must be advertised. Everything else is a single request, blocking
+// when payload.Wait is set. Installs of a named instance need
+// featureAppInstances, as older firmware would ignore the suffix and install
+// over the unsuffixed app.
+func (c *LcmdClient) installPackage(ctx context.Context, route string, payload apiInstallRequest) (*apiAppInfo, error) {
+	if err := c.ready(ctx); err != nil {
+		return nil, err
+	}
+	if payload.AppIDSuffix != "" && !c.advertises(featureAppInstances) {
+		return nil, errors.New("the NAS does not support installing several instances of a package")
+	}
+	started := time.Now()
+	fields := map[string]any{"lpk_url": payload.LPKURL}
+	if payload.StoreAppID != "" {
+		fields = map[string]any{"store_appid": payload.StoreAppID, "channel": payload.Channel}
+	}
+	if !payload.Wait || !c.advertises(featureInstallTasks) {
+		var app apiAppInfo
+		if err := c.do(longRunning(ctx), http.MethodPost, route, nil, &payload, &app); err != nil {
+			return nil, err
+		}
+		if payload.Wait {
+			fields["duration"] = time.Since(started).Round(time.Second).String()
+			tflog.Info(ctx, "installed package", fields)
+		}
+		return &app, nil
+	}
+	payload.Wait = false
+	var task apiInstallTask
+	if err := c.do(ctx, http.MethodPost, route, nil, &payload, &task); err != nil {
+		return nil, err
+	}
+	if task.TaskID == "" {
+		return nil, errors.New("the NAS accepted the install without returning a task to follow")
+	}
+	app, err := c.waitInstallTask(ctx, task.TaskID)
+	if err != nil {
+		return nil, err
+	}
+	fields["duration"] = time.Since(started).Round(time.Second).String()
+	tflog.Info(ctx, "installed package", fields)
+	return app, nil
+}
+
+// waitInstallTask polls taskID until it finishes, logging every change of
+// phase or progress.
+func (c *LcmdClient) waitInstallTask(ctx context.Context, taskID string) (*apiAppInfo, error) {
+	params := map[string]string{"uid": c.User}
+	var last string
+	for {
+		var status apiInstallTaskStatus
+		if err := c.do(ctx, http.MethodGet, path.Join("/v1/apps/tasks", taskID), params, nil, &status); err != nil {
+			// Not wrapped, so a task that expired is not mistaken for a
+			// missing install endpoint.
+			return nil, fmt.Errorf("follow install task %s: %s", taskID, err)
+		}
+		switch status.Phase {
+		case taskPhaseDone:
+			if status.App == nil {
+				return nil, fmt.Errorf("install task %s finished without reporting the app", taskID)
+			}
+			return status.App, nil
+		case taskPhaseFailed:
+			if status.Error == "" {
+				status.Error = "no reason given"
+			}
+			return nil, fmt.Errorf("install failed: %s", status.Error)
+		}
+		progress := status.Phase
+		if status.Progress != nil {
+			progress = fmt.Sprintf("%s %d%%", status.Phase, *status.Progress)
+		}
+		if progress != last {
+			last = progress
+			tflog.Info(ctx, "install progress", map[string]any{
+				"task_id":  taskID,
+				"progress": progress,
+				"message":  status.Message,
+			})
+		}
+		if err := sleepContext(ctx, installPollInterval); err != nil {
+			return nil, fmt.Errorf("install task %s did not finish in time, last at %s: %w", taskID, last, err)
+		}
+	}
+}
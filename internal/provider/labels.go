@@ -0,0 +1,96 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"maps"
+
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// labelsAttributes returns the `labels` and `effective_labels` attributes of
+// resources that support labels. Keeping the merged set in a separate
+// computed attribute means provider `default_labels` never show up as a diff
+// on `labels` itself. client is called at plan time to look up the defaults.
+func labelsAttributes(what string, client func() Client) (schema.MapAttribute, schema.MapAttribute) {
+	return schema.MapAttribute{
+		MarkdownDescription: "Labels attached to the " + what + ". They take precedence over the provider `default_labels`.",
+		ElementType:         types.StringType,
+		Optional:            true,
+	}, schema.MapAttribute{
+		MarkdownDescription: "All labels attached to the " + what + ", including the provider `default_labels`.",
+		ElementType:         types.StringType,
+		Computed:            true,
+		PlanModifiers: []planmodifier.Map{
+			effectiveLabelsModifier{client: client},
+		},
+	}
+}
+
+// effectiveLabelsModifier plans effective_labels as the provider defaults
+// merged with the configured labels, so a change to either is planned as an
+// in-place update.
+type effectiveLabelsModifier struct {
+	client func() Client
+}
+
+func (m effectiveLabelsModifier) Description(_ context.Context) string {
+	return "Merges the provider default_labels with labels."
+}
+
+func (m effectiveLabelsModifier) MarkdownDescription(ctx context.Context) string {
+	return m.Description(ctx)
+}
+
+func (m effectiveLabelsModifier) PlanModifyMap(ctx context.Context, req planmodifier.MapRequest, resp *planmodifier.MapResponse) {
+	if req.Plan.Raw.IsNull() {
+		return
+	}
+	var labels types.Map
+	resp.Diagnostics.Append(req.Plan.GetAttribute(ctx, path.Root("labels"), &labels)...)
+	client := m.client()
+	if resp.Diagnostics.HasError() || labels.IsUnknown() || client == nil {
+		resp.PlanValue = types.MapUnknown(types.StringType)
+		return
+	}
+	merged, diags := mergeLabels(ctx, client.DefaultLabels(), labels)
+	resp.Diagnostics.Append(diags...)
+	if len(merged) == 0 {
+		resp.PlanValue = types.MapNull(types.StringType)
+		return
+	}
+	value, diags := types.MapValueFrom(ctx, types.StringType, merged)
+	resp.Diagnostics.Append(diags...)
+	resp.PlanValue = value
+}
+
+// mergeLabels returns defaults overlaid with labels, or nil when both are
+// empty.
+func mergeLabels(ctx context.Context, defaults map[string]string, labels types.Map) (map[string]string, diag.Diagnostics) {
+	own, diags := labelsMap(ctx, labels)
+	if len(defaults) == 0 && len(own) == 0 {
+		return nil, diags
+	}
+	merged := maps.Clone(defaults)
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	maps.Copy(merged, own)
+	return merged, diags
+}
+
+// labelsMap converts a labels value into a plain map, treating null and
+// unknown as no labels.
+func labelsMap(ctx context.Context, labels types.Map) (map[string]string, diag.Diagnostics) {
+	if labels.IsNull() || labels.IsUnknown() {
+		return nil, nil
+	}
+	var out map[string]string
+	diags := labels.ElementsAs(ctx, &out, false)
+	return out, diags
+}
@@ -0,0 +1,116 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+)
+
+// Values of source.git.backend.
+const (
+	gitBackendGoGit = "go-git"
+	gitBackendExec  = "exec"
+)
+
+var gitBackends = []string{gitBackendGoGit, gitBackendExec}
+
+// cloneGoGit clones ref into repoPath with go-git, which needs no git
+// binary, and returns the commit checked out. Like cloneExec, branches and
+// tags are cloned directly and a commit is checked out after the clone.
+func (s *gitSource) cloneGoGit(ctx context.Context, repoPath, ref string, commit bool, depth int64) (string, error) {
+	opts := &git.CloneOptions{
+		URL:          s.model.URL.ValueString(),
+		Depth:        int(depth),
+		SingleBranch: s.singleBranch(depth),
+		NoCheckout:   commit,
+	}
+	var repo *git.Repository
+	var err error
+	if ref == "" || commit {
+		repo, err = git.PlainCloneContext(ctx, repoPath, false, opts)
+	} else {
+		repo, err = cloneGoGitRef(ctx, repoPath, ref, opts)
+	}
+	if err != nil {
+		return "", fmt.Errorf("git clone failed: %s", redactURLUserinfo(err.Error()))
+	}
+	if commit {
+		if err := checkoutGoGitCommit(ctx, repo, ref, depth); err != nil {
+			return "", err
+		}
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return "", fmt.Errorf("resolve HEAD: %w", err)
+	}
+	tflog.Debug(ctx, "cloned git source with go-git", map[string]any{
+		"url":      redactURLUserinfo(opts.URL),
+		"ref":      ref,
+		"revision": head.Hash().String(),
+	})
+	return head.Hash().String(), nil
+}
+
+// cloneGoGitRef clones the branch or tag ref. go-git needs the full
+// reference name, so a short name is tried as a branch and then as a tag,
+// as git clone --branch does.
+func cloneGoGitRef(ctx context.Context, repoPath, ref string, opts *git.CloneOptions) (*git.Repository, error) {
+	names := []plumbing.ReferenceName{plumbing.NewBranchReferenceName(ref), plumbing.NewTagReferenceName(ref)}
+	if strings.HasPrefix(ref, "refs/") {
+		names = []plumbing.ReferenceName{plumbing.ReferenceName(ref)}
+	}
+	var err error
+	for _, name := range names {
+		opts.ReferenceName = name
+		var repo *git.Repository
+		repo, err = git.PlainCloneContext(ctx, repoPath, false, opts)
+		if err == nil {
+			return repo, nil
+		}
+		if !errors.Is(err, plumbing.ErrReferenceNotFound) && !errors.Is(err, git.NoMatchingRefSpecError{}) {
+			return nil, err
+		}
+		if err := os.RemoveAll(repoPath); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("ref %q not found in the remote repository: %w", ref, err)
+}
+
+// checkoutGoGitCommit checks out the commit ref, fetching it first when the
+// clone is shallow and may not contain it.
+func checkoutGoGitCommit(ctx context.Context, repo *git.Repository, ref string, depth int64) error {
+	if depth > 0 {
+		err := repo.FetchContext(ctx, &git.FetchOptions{
+			RefSpecs: []config.RefSpec{config.RefSpec(ref + ":refs/lcmd/commit")},
+			Depth:    int(depth),
+		})
+		if errors.Is(err, git.ErrExactSHA1NotSupported) {
+			return fmt.Errorf("git fetch failed: the server cannot fetch a commit by SHA; set git.depth = 0 to clone the full history: %w", err)
+		}
+		if err != nil && !errors.Is(err, git.NoErrAlreadyUpToDate) {
+			return fmt.Errorf("git fetch failed: %s", redactURLUserinfo(err.Error()))
+		}
+	}
+	hash, err := repo.ResolveRevision(plumbing.Revision(ref))
+	if err != nil {
+		return fmt.Errorf("git checkout failed: %w", err)
+	}
+	worktree, err := repo.Worktree()
+	if err != nil {
+		return fmt.Errorf("git checkout failed: %w", err)
+	}
+	if err := worktree.Checkout(&git.CheckoutOptions{Hash: *hash}); err != nil {
+		return fmt.Errorf("git checkout failed: %w", err)
+	}
+	return nil
+}
@@ -11,77 +11,136 @@ import (
 	"fmt"
 	"io"
 	"io/fs"
+	"maps"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 	"text/template"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
 	resourcevalidator "github.com/hashicorp/terraform-plugin-framework-validators/resourcevalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
 	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"gopkg.in/yaml.v3"
 )
 
 var _ resource.Resource = &LPKBuildResource{}
 var _ resource.ResourceWithConfigValidators = &LPKBuildResource{}
+var _ resource.ResourceWithUpgradeState = &LPKBuildResource{}
+var _ resource.ResourceWithModifyPlan = &LPKBuildResource{}
+var _ resource.ResourceWithImportState = &LPKBuildResource{}
 
 type LPKBuildResource struct {
-	client *LcmdClient
+	client Client
 }
 
 type LPKBuildModel struct {
-	ID         types.String          `tfsdk:"id"`
-	Source     *LPKBuildSourceModel  `tfsdk:"source"`
-	Build      *LPKBuildBuildModel   `tfsdk:"build"`
-	Publish    *LPKBuildPublishModel `tfsdk:"publish"`
-	Env        *LPKBuildEnvModel     `tfsdk:"env"`
-	LPKURL     types.String          `tfsdk:"lpk_url"`
-	SHA256     types.String          `tfsdk:"sha256"`
-	AppID      types.String          `tfsdk:"appid"`
-	Version    types.String          `tfsdk:"version"`
-	LocalPath  types.String          `tfsdk:"local_path"`
-	UploadID   types.String          `tfsdk:"upload_id"`
-	SourceHash types.String          `tfsdk:"source_hash"`
+	ID               types.String            `tfsdk:"id"`
+	Box              types.String            `tfsdk:"box"`
+	Source           *LPKBuildSourceModel    `tfsdk:"source"`
+	Build            *LPKBuildBuildModel     `tfsdk:"build"`
+	Publish          *LPKBuildPublishModel   `tfsdk:"publish"`
+	Env              *LPKBuildEnvModel       `tfsdk:"env"`
+	LPKURL           types.String            `tfsdk:"lpk_url"`
+	SHA256           types.String            `tfsdk:"sha256"`
+	AppID            types.String            `tfsdk:"appid"`
+	Version          types.String            `tfsdk:"version"`
+	LocalPath        types.String            `tfsdk:"local_path"`
+	UploadID         types.String            `tfsdk:"upload_id"`
+	SourceHash       types.String            `tfsdk:"source_hash"`
+	SourceRevision   types.String            `tfsdk:"source_revision"`
+	Checksums        types.Map               `tfsdk:"content_checksums"`
+	UID              types.String            `tfsdk:"uid"`
+	ManifestSHA      types.String            `tfsdk:"manifest_sha256"`
+	Retention        *LPKBuildRetentionModel `tfsdk:"output_retention"`
+	Stats            types.Object            `tfsdk:"stats"`
+	Timeouts         *TimeoutsModel          `tfsdk:"timeouts"`
+	ManifestOverride types.String            `tfsdk:"manifest_override"`
+	Labels           types.Map               `tfsdk:"labels"`
+	EffectiveLabels  types.Map               `tfsdk:"effective_labels"`
 }
 
 type LPKBuildSourceModel struct {
-	Local *LPKBuildSourceLocalModel `tfsdk:"local"`
-	Git   *LPKBuildSourceGitModel   `tfsdk:"git"`
+	Local    *LPKBuildSourceLocalModel    `tfsdk:"local"`
+	Git      *LPKBuildSourceGitModel      `tfsdk:"git"`
+	Archive  *LPKBuildSourceArchiveModel  `tfsdk:"archive"`
+	External *LPKBuildSourceExternalModel `tfsdk:"external"`
+	Ignore   types.List                   `tfsdk:"ignore"`
 }
 
 type LPKBuildSourceLocalModel struct {
-	Path types.String `tfsdk:"path"`
+	Path    types.String `tfsdk:"path"`
+	InPlace types.Bool   `tfsdk:"in_place"`
 }
 
 type LPKBuildSourceGitModel struct {
-	URL     types.String `tfsdk:"url"`
-	Ref     types.String `tfsdk:"ref"`
-	Subpath types.String `tfsdk:"subpath"`
+	URL            types.String `tfsdk:"url"`
+	Ref            types.String `tfsdk:"ref"`
+	Subpath        types.String `tfsdk:"subpath"`
+	Depth          types.Int64  `tfsdk:"depth"`
+	SingleBranch   types.Bool   `tfsdk:"single_branch"`
+	SparseCheckout types.Bool   `tfsdk:"sparse_checkout"`
+	Commit         types.String `tfsdk:"commit"`
+	LFS            types.Bool   `tfsdk:"lfs"`
+	Backend        types.String `tfsdk:"backend"`
+}
+
+type LPKBuildSourceArchiveModel struct {
+	Location types.String `tfsdk:"location"`
+	Subpath  types.String `tfsdk:"subpath"`
+}
+
+type LPKBuildSourceExternalModel struct {
+	Command    types.String `tfsdk:"command"`
+	WorkingDir types.String `tfsdk:"working_dir"`
 }
 
 type LPKBuildBuildModel struct {
-	Command types.String `tfsdk:"command"`
+	Command      types.String `tfsdk:"command"`
+	OutputDir    types.String `tfsdk:"output_dir"`
+	NameTemplate types.String `tfsdk:"artifact_name_template"`
 }
 
 type LPKBuildPublishModel struct {
-	Enabled types.Bool   `tfsdk:"enabled"`
-	Name    types.String `tfsdk:"name"`
-	Version types.String `tfsdk:"version"`
+	Enabled          types.Bool   `tfsdk:"enabled"`
+	Name             types.String `tfsdk:"name"`
+	Version          types.String `tfsdk:"version"`
+	DeleteSuperseded types.Bool   `tfsdk:"delete_superseded"`
+	RegistryURL      types.String `tfsdk:"registry_url"`
+	Overwrite        types.String `tfsdk:"overwrite"`
 }
 
 type LPKBuildEnvModel struct {
-	Variables         map[string]types.String `tfsdk:"variables"`
-	TemplateExtension types.String            `tfsdk:"template_extension"`
+	Variables         map[string]types.String            `tfsdk:"variables"`
+	SensitiveVars     map[string]types.String            `tfsdk:"sensitive_variables"`
+	WriteOnlyVars     map[string]types.String            `tfsdk:"variables_wo"`
+	WriteOnlyVersion  types.String                       `tfsdk:"variables_wo_version"`
+	Files             types.List                         `tfsdk:"files"`
+	OnMissing         types.String                       `tfsdk:"on_missing"`
+	Engine            types.String                       `tfsdk:"engine"`
+	Defaults          map[string]types.String            `tfsdk:"defaults"`
+	TemplateExtension types.String                       `tfsdk:"template_extension"`
+	ServicesEnv       map[string]map[string]types.String `tfsdk:"services_env"`
 }
 
 const defaultTemplateExtension = ".tmpl"
 
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{40}([0-9a-f]{24})?$`)
+
 func NewLPKBuildResource() resource.Resource {
 	return &LPKBuildResource{}
 }
@@ -95,17 +154,23 @@ func (r *LPKBuildResource) ConfigValidators(_ context.Context) []resource.Config
 		resourcevalidator.AtLeastOneOf(
 			path.MatchRoot("source").AtName("local"),
 			path.MatchRoot("source").AtName("git"),
+			path.MatchRoot("source").AtName("archive"),
+			path.MatchRoot("source").AtName("external"),
 		),
 		resourcevalidator.Conflicting(
 			path.MatchRoot("source").AtName("local"),
 			path.MatchRoot("source").AtName("git"),
+			path.MatchRoot("source").AtName("archive"),
+			path.MatchRoot("source").AtName("external"),
 		),
 	}
 }
 
 func (r *LPKBuildResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	labels, effectiveLabels := labelsAttributes("uploaded package", func() Client { return r.client })
 	resp.Schema = schema.Schema{
 		Description: "Builds an LPK from source and optionally uploads it to the NAS registry.",
+		Version:     lpkBuildSchemaVersion,
 		Attributes: map[string]schema.Attribute{
 			"id": schema.StringAttribute{
 				Computed:    true,
@@ -114,6 +179,15 @@ func (r *LPKBuildResource) Schema(_ context.Context, _ resource.SchemaRequest, r
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"box": boxAttribute(),
+			"uid": schema.StringAttribute{
+				Optional:    true,
+				Description: "NAS user the artifact is published for. Defaults to the provider user.",
+			},
+			"manifest_sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA256 of the packaged manifest normalized to JSON, for use as lcmd_app.expected_manifest_sha.",
+			},
 			"lpk_url": schema.StringAttribute{Computed: true, Description: "Download URL returned by NAS registry."},
 			"sha256":  schema.StringAttribute{Computed: true},
 			"appid":   schema.StringAttribute{Computed: true},
@@ -125,11 +199,27 @@ func (r *LPKBuildResource) Schema(_ context.Context, _ resource.SchemaRequest, r
 			"upload_id": schema.StringAttribute{Computed: true},
 			"source_hash": schema.StringAttribute{
 				Computed:    true,
-				Description: "Hash of the source directory used to detect local changes.",
+				Description: "Hash over the path and content of every file in the source tree, except source.ignore, VCS metadata, built artifacts, and files rendered from a template. A plan rebuilds the package when it changes and is a no-op otherwise.",
 				PlanModifiers: []planmodifier.String{
 					stringplanmodifier.UseStateForUnknown(),
 				},
 			},
+			"source_revision": schema.StringAttribute{
+				Computed:    true,
+				Description: "Commit SHA a git source was built from. Null for other sources.",
+			},
+			"content_checksums": schema.MapAttribute{
+				Computed:    true,
+				ElementType: types.StringType,
+				Description: "SHA256 checksum of every file in the staged source, keyed by slash-separated relative path.",
+			},
+			"stats": buildStatsAttribute(),
+			"manifest_override": schema.StringAttribute{
+				Optional:    true,
+				Description: "JSON object, e.g. from jsonencode(), deep-merged onto lzc-manifest.yml before packaging. Nested objects are merged key by key, other values replace the manifest value, and null removes a key. The source tree is left unchanged.",
+			},
+			"labels":           labels,
+			"effective_labels": effectiveLabels,
 			"source": schema.SingleNestedAttribute{
 				Required: true,
 				Attributes: map[string]schema.Attribute{
@@ -137,23 +227,103 @@ func (r *LPKBuildResource) Schema(_ context.Context, _ resource.SchemaRequest, r
 						Optional: true,
 						Attributes: map[string]schema.Attribute{
 							"path": schema.StringAttribute{Required: true},
+							"in_place": schema.BoolAttribute{
+								Optional:    true,
+								Description: "Render templates and build in path itself, writing rendered files next to their templates, instead of in a temporary copy that leaves the tree untouched. The artifact is kept in path either way unless build.output_dir is set. Defaults to false.",
+							},
 						},
 					},
 					"git": schema.SingleNestedAttribute{
 						Optional: true,
 						Attributes: map[string]schema.Attribute{
-							"url":     schema.StringAttribute{Required: true},
-							"ref":     schema.StringAttribute{Optional: true},
-							"subpath": schema.StringAttribute{Optional: true},
+							"url": schema.StringAttribute{Required: true},
+							"ref": schema.StringAttribute{Optional: true},
+							"subpath": schema.StringAttribute{
+								Optional:    true,
+								Description: "Directory within the repository to build from. Must be a relative path that stays within it.",
+								Validators:  []validator.String{relativePathValidator{}},
+							},
+							"depth": schema.Int64Attribute{
+								Optional:    true,
+								Description: "Number of commits of history to clone, or 0 for the full history. Defaults to 1, or 0 when ref or commit selects a commit SHA. Cloning a commit at a depth requires its full SHA.",
+								Validators:  []validator.Int64{int64validator.AtLeast(0)},
+							},
+							"single_branch": schema.BoolAttribute{
+								Optional:    true,
+								Description: "Clone only the history of ref, or of the default branch when ref is unset. Defaults to true for shallow clones and false otherwise.",
+							},
+							"commit": schema.StringAttribute{
+								Optional:    true,
+								Description: "Full SHA of the commit to build. It is checked out when ref is unset, and otherwise must be the commit ref resolves to, so a moved branch or tag fails the build instead of building unreviewed code.",
+								Validators: []validator.String{
+									stringvalidator.RegexMatches(commitSHAPattern, "must be a full lowercase commit SHA"),
+								},
+							},
+							"lfs": schema.BoolAttribute{
+								Optional:    true,
+								Description: "Fetch Git LFS files instead of building with their pointer files. Requires git-lfs. Defaults to whether a .gitattributes at the repository root or in subpath uses the LFS filter.",
+							},
+							"sparse_checkout": schema.BoolAttribute{
+								Optional:    true,
+								Description: "When subpath is set, check out only that directory and the files at the repository root, fetching no other file contents. Disable it for builds that read other directories of the repository. Needs the exec backend. Defaults to true, or false with the go-git backend.",
+							},
+							"backend": schema.StringAttribute{
+								Optional:    true,
+								Description: "How the repository is cloned: go-git, built into the provider, or exec, which runs the git binary and honours its credential helpers and SSH configuration. Defaults to go-git, or exec when sparse_checkout applies. file:// URLs and LFS files still need the git binary.",
+								Validators: []validator.String{
+									stringvalidator.OneOf(gitBackends...),
+								},
+							},
+						},
+					},
+					"archive": schema.SingleNestedAttribute{
+						Optional:    true,
+						Description: "Zip or tar(.gz) archive containing the source.",
+						Attributes: map[string]schema.Attribute{
+							"location": schema.StringAttribute{
+								Required:    true,
+								Description: "Local path or http(s) URL of the archive.",
+							},
+							"subpath": schema.StringAttribute{
+								Optional:    true,
+								Description: "Directory within the archive to build from. Must be a relative path that stays within it.",
+								Validators:  []validator.String{relativePathValidator{}},
+							},
+						},
+					},
+					"external": schema.SingleNestedAttribute{
+						Optional:    true,
+						Description: "Runs a command that produces the source directory, for sources the provider does not support natively.",
+						Attributes: map[string]schema.Attribute{
+							"command": schema.StringAttribute{
+								Required:    true,
+								Description: "Shell command run with LPK_SOURCE_DIR set to an empty directory. It either populates that directory or prints the path of the prepared directory as its last line of output.",
+							},
+							"working_dir": schema.StringAttribute{Optional: true},
 						},
 					},
+					"ignore": schema.ListAttribute{
+						Optional:    true,
+						ElementType: types.StringType,
+						Description: "Patterns of files, e.g. node_modules/ or *.log, whose changes do not rebuild the package. A pattern without a slash matches names at any depth, one with a slash matches paths from the source root, and a trailing slash only matches directories. The files are still built.",
+					},
 				},
 			},
 		},
 		Blocks: map[string]schema.Block{
+			"timeouts": timeoutsBlock(),
 			"build": schema.SingleNestedBlock{
 				Attributes: map[string]schema.Attribute{
 					"command": schema.StringAttribute{Optional: true},
+					"output_dir": schema.StringAttribute{
+						Optional:    true,
+						Description: "Directory the artifact is moved to after the build, e.g. a dist directory outside the source tree. Relative paths are resolved against the Terraform working directory. Defaults to the source directory.",
+					},
+					"artifact_name_template": schema.StringAttribute{
+						Optional:    true,
+						Description: "Go template for the artifact file name, with the manifest name as {{.Name}}, its version as {{.Version}}, and the SHA256 of lzc-manifest.yml as {{.SHA}}. The .lpk extension is added when missing. Defaults to {{.Name}}-{{.Version}}-{{.SHA}}.",
+						Validators:  []validator.String{artifactNameTemplateValidator{}},
+					},
 				},
 			},
 			"publish": schema.SingleNestedBlock{
@@ -161,6 +331,34 @@ func (r *LPKBuildResource) Schema(_ context.Context, _ resource.SchemaRequest, r
 					"enabled": schema.BoolAttribute{Optional: true},
 					"name":    schema.StringAttribute{Optional: true},
 					"version": schema.StringAttribute{Optional: true},
+					"delete_superseded": schema.BoolAttribute{
+						Optional:    true,
+						Description: "Delete the previous registry upload after a rebuild publishes a new one. Defaults to true.",
+					},
+					"registry_url": schema.StringAttribute{
+						Optional:    true,
+						Description: "Base URL of the registry API to upload to. Defaults to default_publish.registry_url, then the provider endpoint.",
+					},
+					"overwrite": schema.StringAttribute{
+						Optional:    true,
+						Description: "Overwrite policy for an existing upload with the same name and version: always, never, or if_changed. Defaults to default_publish.overwrite.",
+						Validators: []validator.String{
+							stringvalidator.OneOf(overwritePolicies...),
+						},
+					},
+				},
+			},
+			"output_retention": schema.SingleNestedBlock{
+				Description: "Prunes older artifacts the provider built into the same directory as the current one. The current artifact is always kept.",
+				Attributes: map[string]schema.Attribute{
+					"max_artifacts": schema.Int64Attribute{
+						Optional:    true,
+						Description: "Maximum number of artifacts to keep, including the current one.",
+					},
+					"max_age": schema.StringAttribute{
+						Optional:    true,
+						Description: "Remove artifacts last modified longer ago than this Go duration, e.g. 168h.",
+					},
 				},
 			},
 			"env": schema.SingleNestedBlock{
@@ -170,9 +368,58 @@ func (r *LPKBuildResource) Schema(_ context.Context, _ resource.SchemaRequest, r
 						ElementType: types.StringType,
 						Description: "Key-value pairs exposed to template rendering and build commands.",
 					},
+					"sensitive_variables": schema.MapAttribute{
+						Optional:    true,
+						Sensitive:   true,
+						ElementType: types.StringType,
+						Description: "Like variables, for secrets such as registry tokens: values are exposed to template rendering and build commands but redacted from plan output and logs. A key set in both maps takes this value.",
+					},
+					"variables_wo": schema.MapAttribute{
+						Optional:    true,
+						Sensitive:   true,
+						WriteOnly:   true,
+						ElementType: types.StringType,
+						Description: "Write-only secrets, e.g. a registry password or npm token, exposed to template rendering and build commands like sensitive_variables but never stored in state or plans. Changes are not detected; change variables_wo_version to rebuild with new values. Requires Terraform 1.11 or later. A key set here takes precedence over the other maps.",
+					},
+					"variables_wo_version": schema.StringAttribute{
+						Optional:    true,
+						Description: "Arbitrary value to change whenever variables_wo changes, which rebuilds the package with the new secrets.",
+					},
+					"files": schema.ListAttribute{
+						Optional:    true,
+						ElementType: types.StringType,
+						Description: "Dotenv files, e.g. .env.production, relative to the source directory, whose KEY=value lines are exposed like variables. Later files override earlier ones, and every variables map overrides them.",
+						Validators: []validator.List{
+							listvalidator.ValueStringsAre(relativePathValidator{}),
+						},
+					},
+					"on_missing": schema.StringAttribute{
+						Optional:    true,
+						Description: "What a template reference to a variable that is not set renders: error fails the build, empty renders an empty string, and keep leaves the {{.KEY}} reference in the output for a later stage to fill in. Defaults to error.",
+						Validators: []validator.String{
+							stringvalidator.OneOf(onMissingModes...),
+						},
+					},
+					"engine": schema.StringAttribute{
+						Optional:    true,
+						Description: "Template engine: gotemplate, with variables referenced as {{.KEY}}, or jinja2, with {{ KEY }}, for repositories shipping .j2 templates. The jinja2 engine renders with pongo2, which covers for, if, set, include, import, and macro tags, and Django-style filters such as {{ KEY|default:\"x\" }}. Defaults to gotemplate.",
+						Validators: []validator.String{
+							stringvalidator.OneOf(templateEngines...),
+						},
+					},
+					"defaults": schema.MapAttribute{
+						Optional:    true,
+						ElementType: types.StringType,
+						Description: "Values of variables that files and the variables maps leave unset, so optional template variables need not be set everywhere.",
+					},
 					"template_extension": schema.StringAttribute{
 						Optional:    true,
-						Description: "File extension (e.g., .tmpl or .j2) considered a template. Defaults to .tmpl.",
+						Description: "File extension (e.g., .tmpl or .j2) considered a template. Defaults to .j2 for the jinja2 engine and .tmpl otherwise.",
+					},
+					"services_env": schema.MapAttribute{
+						Optional:    true,
+						ElementType: types.MapType{ElemType: types.StringType},
+						Description: "Per-service environment variables keyed by service name and merged into that service's environment section of lzc-manifest.yml before packaging.",
 					},
 				},
 			},
@@ -184,9 +431,9 @@ func (r *LPKBuildResource) Configure(_ context.Context, req resource.ConfigureRe
 	if req.ProviderData == nil {
 		return
 	}
-	client, ok := req.ProviderData.(*LcmdClient)
+	client, ok := req.ProviderData.(Client)
 	if !ok {
-		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected *LcmdClient, got %T", req.ProviderData))
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected Client, got %T", req.ProviderData))
 		return
 	}
 	r.client = client
@@ -199,10 +446,17 @@ func (r *LPKBuildResource) Create(ctx context.Context, req resource.CreateReques
 	}
 	var plan LPKBuildModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(readWriteOnlyVars(ctx, req.Config, &plan)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	result, err := r.applyBuild(ctx, &plan, nil)
+	client := boxClient(r.client, plan.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	ctx, cancel := plan.Timeouts.create(ctx)
+	defer cancel()
+	result, err := r.applyBuild(ctx, client, &plan, nil)
 	if err != nil {
 		resp.Diagnostics.AddError("Build error", err.Error())
 		return
@@ -220,30 +474,196 @@ func (r *LPKBuildResource) Read(ctx context.Context, req resource.ReadRequest, r
 		resp.State.RemoveResource(ctx)
 		return
 	}
-	if state.Source == nil {
+	// Imported builds have no source until the next apply builds one.
+	if state.Source == nil && state.UploadID.IsNull() {
 		resp.Diagnostics.AddError("Missing source", "state is missing source definition")
 		resp.State.RemoveResource(ctx)
 		return
 	}
-	path, cleanup, err := r.prepareSource(ctx, state.Source)
-	if err != nil {
-		resp.Diagnostics.AddError("Source error", err.Error())
-		resp.State.RemoveResource(ctx)
-		return
+	// An artifact or upload that is gone is cleared, so ModifyPlan plans
+	// the rebuild or upload again instead of pointing at a dead URL.
+	// Changed sources are also left to ModifyPlan.
+	if local := state.LocalPath.ValueString(); local != "" {
+		if _, err := os.Stat(local); errors.Is(err, os.ErrNotExist) {
+			tflog.Info(ctx, "built artifact is missing", map[string]any{"local_path": local})
+			state.LocalPath = types.StringNull()
+		}
 	}
-	if cleanup != nil {
-		defer cleanup()
+	if r.client != nil && state.UploadID.ValueString() != "" {
+		client := boxClient(r.client, state.Box, &resp.Diagnostics)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		registry, err := resolvePublish(state.Publish, client.PublishDefaults()).registryClient(client)
+		if err != nil {
+			resp.Diagnostics.AddError("Read upload failed", err.Error())
+			return
+		}
+		_, err = userClient(registry, state.UID).GetLPK(ctx, state.UploadID.ValueString())
+		switch {
+		case errors.Is(err, errNotFound):
+			tflog.Info(ctx, "upload is no longer in the registry", map[string]any{"upload_id": state.UploadID.ValueString()})
+			state.UploadID = types.StringNull()
+			state.LPKURL = types.StringNull()
+		case err != nil:
+			resp.Diagnostics.AddError("Read upload failed", err.Error())
+			return
+		}
 	}
-	fingerprint, err := hashDirectory(path)
-	if err != nil {
-		resp.Diagnostics.AddError("Hash error", err.Error())
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+// ModifyPlan schedules a rebuild when the source fingerprint changed, the
+// artifact is gone from disk, or the package is published elsewhere, and
+// warns with the reasons so the plan says why. An unchanged build plans no
+// change, whatever files a change touched.
+func (r *LPKBuildResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || req.State.Raw.IsNull() {
 		return
 	}
-	if state.SourceHash.IsNull() || state.SourceHash.ValueString() != fingerprint {
-		resp.State.RemoveResource(ctx)
+	var plan, state LPKBuildModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
 		return
 	}
-	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+	var reasons []string
+	if state.SourceHash.IsNull() {
+		reasons = append(reasons, "it was imported and has not been built from source yet")
+	} else {
+		changed, diags := r.sourceChanged(ctx, req.Plan, &plan, &state)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		if changed {
+			reasons = append(reasons, "the source changed since the last build")
+		}
+		if reason := artifactProblem(ctx, &state); reason != "" {
+			reasons = append(reasons, reason)
+		}
+	}
+	var defaults publishDefaults
+	if r.client != nil {
+		defaults = r.client.PublishDefaults()
+	}
+	if resolvePublish(state.Publish, defaults).enabled && state.UploadID.IsNull() && resolvePublish(plan.Publish, defaults).enabled {
+		reasons = append(reasons, "the upload is no longer in the registry")
+	}
+	if plan.Env != nil && !plan.Env.WriteOnlyVersion.IsUnknown() && !plan.Env.WriteOnlyVersion.Equal(writeOnlyVersion(state.Env)) {
+		reasons = append(reasons, "env.variables_wo_version changed")
+	}
+	if fields := outputChanges(&plan, &state); len(fields) > 0 {
+		reasons = append(reasons, fmt.Sprintf("the artifact location changed (%s)", strings.Join(fields, ", ")))
+	}
+	if fields := publishChanges(&plan, &state, defaults); len(fields) > 0 {
+		reasons = append(reasons, fmt.Sprintf("the publish target changed (%s)", strings.Join(fields, ", ")))
+	}
+	if len(reasons) == 0 {
+		return
+	}
+	action := "rebuilt"
+	if resolvePublish(plan.Publish, defaults).enabled {
+		action = "rebuilt and uploaded"
+	}
+	resp.Diagnostics.AddWarning("Package rebuild planned", fmt.Sprintf("The package will be %s because %s.", action, strings.Join(reasons, "; ")))
+	// Sources other than local ones may move again before the apply, so
+	// the hash is only known once the rebuild ran.
+	plan.SourceHash = types.StringUnknown()
+	plan.SourceRevision = types.StringUnknown()
+	plan.ID = types.StringUnknown()
+	plan.LPKURL = types.StringUnknown()
+	plan.SHA256 = types.StringUnknown()
+	plan.AppID = types.StringUnknown()
+	plan.Version = types.StringUnknown()
+	plan.LocalPath = types.StringUnknown()
+	plan.UploadID = types.StringUnknown()
+	plan.ManifestSHA = types.StringUnknown()
+	plan.Checksums = types.MapUnknown(types.StringType)
+	plan.Stats = types.ObjectUnknown(buildStatsAttrTypes)
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+// sourceChanged reports whether the fingerprint of the planned source
+// differs from the one recorded in state. A source with unknown values is
+// not checked.
+func (r *LPKBuildResource) sourceChanged(ctx context.Context, config tfsdk.Plan, plan, state *LPKBuildModel) (bool, diag.Diagnostics) {
+	var source types.Object
+	diags := config.GetAttribute(ctx, path.Root("source"), &source)
+	if diags.HasError() || plan.Source == nil {
+		return false, diags
+	}
+	if raw, err := source.ToTerraformValue(ctx); err != nil || !raw.IsFullyKnown() {
+		return false, diags
+	}
+	ignore, err := parseSourceIgnore(ctx, plan.Source)
+	if err != nil {
+		diags.AddAttributeError(path.Root("source").AtName("ignore"), "Invalid ignore pattern", err.Error())
+		return false, diags
+	}
+	dir, provider, err := r.prepareSource(ctx, plan.Source)
+	if provider != nil {
+		defer provider.Cleanup()
+	}
+	if err != nil {
+		diags.AddError("Source error", err.Error())
+		return false, diags
+	}
+	fingerprint, err := provider.Fingerprint(dir, resolveTemplateExtension(plan.Env), ignore)
+	if err != nil {
+		diags.AddError("Hash error", err.Error())
+		return false, diags
+	}
+	return fingerprint != state.SourceHash.ValueString(), diags
+}
+
+// artifactProblem describes why the artifact recorded in state can no
+// longer be used, or returns "" when it still matches its source.
+func artifactProblem(ctx context.Context, state *LPKBuildModel) string {
+	local := state.LocalPath.ValueString()
+	if local == "" {
+		return "the artifact is missing on disk"
+	}
+	if _, err := os.Stat(local); err != nil {
+		return fmt.Sprintf("the artifact %s is missing on disk", local)
+	}
+	if state.Checksums.IsNull() {
+		return ""
+	}
+	var recorded map[string]string
+	if diags := state.Checksums.ElementsAs(ctx, &recorded, false); diags.HasError() {
+		return ""
+	}
+	if !artifactMatchesChecksums(local, recorded) {
+		return fmt.Sprintf("the artifact %s no longer matches the source it was built from", local)
+	}
+	return ""
+}
+
+// publishChanges names the publish settings that differ between plan and
+// state, after applying defaults. Nothing counts once publishing is off.
+func publishChanges(plan, state *LPKBuildModel, defaults publishDefaults) []string {
+	was, now := resolvePublish(state.Publish, defaults), resolvePublish(plan.Publish, defaults)
+	var fields []string
+	if was.enabled != now.enabled {
+		fields = append(fields, "publish.enabled")
+	}
+	if !now.enabled {
+		return fields
+	}
+	if was.registryURL != now.registryURL {
+		fields = append(fields, "publish.registry_url")
+	}
+	if was.name != now.name {
+		fields = append(fields, "publish.name")
+	}
+	if was.version != now.version {
+		fields = append(fields, "publish.version")
+	}
+	if !plan.UID.IsUnknown() && !plan.UID.Equal(state.UID) {
+		fields = append(fields, "uid")
+	}
+	return fields
 }
 
 func (r *LPKBuildResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
@@ -254,15 +674,49 @@ func (r *LPKBuildResource) Update(ctx context.Context, req resource.UpdateReques
 	var plan, state LPKBuildModel
 	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
 	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	resp.Diagnostics.Append(readWriteOnlyVars(ctx, req.Config, &plan)...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	result, err := r.applyBuild(ctx, &plan, &state)
+	client := boxClient(r.client, plan.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	ctx, cancel := plan.Timeouts.update(ctx)
+	defer cancel()
+	result, err := r.applyBuild(ctx, client, &plan, &state)
 	if err != nil {
 		resp.Diagnostics.AddError("Build error", err.Error())
 		return
 	}
 	resp.Diagnostics.Append(resp.State.Set(ctx, result)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	r.deleteSuperseded(ctx, client, &state, result, &resp.Diagnostics)
+}
+
+// deleteSuperseded removes the upload recorded in prior once result points at
+// a different one, so rebuilds do not leave orphaned packages in the registry.
+// Failures only warn: the new artifact is already published and in state.
+func (r *LPKBuildResource) deleteSuperseded(ctx context.Context, client Client, prior, result *LPKBuildModel, diags *diag.Diagnostics) {
+	if result.Publish != nil && !result.Publish.DeleteSuperseded.IsNull() && !result.Publish.DeleteSuperseded.ValueBool() {
+		return
+	}
+	old := prior.UploadID.ValueString()
+	if old == "" || old == result.UploadID.ValueString() {
+		return
+	}
+	registry, err := resolvePublish(prior.Publish, client.PublishDefaults()).registryClient(client)
+	if err != nil {
+		diags.AddWarning("Delete superseded upload failed", err.Error())
+		return
+	}
+	if err := registry.DeleteLPK(ctx, old); err != nil && !errors.Is(err, errNotFound) {
+		diags.AddWarning("Delete superseded upload failed", fmt.Sprintf("The previous upload %s was not removed from the registry: %s", old, err))
+		return
+	}
+	tflog.Debug(ctx, "deleted superseded upload", map[string]any{"upload_id": old})
 }
 
 func (r *LPKBuildResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
@@ -271,8 +725,19 @@ func (r *LPKBuildResource) Delete(ctx context.Context, req resource.DeleteReques
 	if resp.Diagnostics.HasError() {
 		return
 	}
-	if r.client != nil && !state.UploadID.IsNull() && state.UploadID.ValueString() != "" {
-		if err := r.client.DeleteLPK(ctx, state.UploadID.ValueString()); err != nil && !errors.Is(err, errNotFound) {
+	client := boxClient(r.client, state.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	ctx, cancel := state.Timeouts.delete(ctx)
+	defer cancel()
+	if client != nil && !state.UploadID.IsNull() && state.UploadID.ValueString() != "" {
+		registry, err := resolvePublish(state.Publish, client.PublishDefaults()).registryClient(client)
+		if err != nil {
+			resp.Diagnostics.AddError("Delete upload failed", err.Error())
+			return
+		}
+		if err := registry.DeleteLPK(ctx, state.UploadID.ValueString()); err != nil && !errors.Is(err, errNotFound) {
 			resp.Diagnostics.AddError("Delete upload failed", err.Error())
 			return
 		}
@@ -282,58 +747,215 @@ func (r *LPKBuildResource) Delete(ctx context.Context, req resource.DeleteReques
 			resp.Diagnostics.AddError("Remove artifact failed", err.Error())
 			return
 		}
+		if err := os.Remove(state.LocalPath.ValueString() + checksumManifestSuffix); err != nil && !errors.Is(err, os.ErrNotExist) {
+			resp.Diagnostics.AddError("Remove artifact failed", err.Error())
+			return
+		}
 	}
 	resp.State.RemoveResource(ctx)
 }
 
-func (r *LPKBuildResource) applyBuild(ctx context.Context, data *LPKBuildModel, prior *LPKBuildModel) (*LPKBuildModel, error) {
-	workdir, cleanup, err := r.prepareSource(ctx, data.Source)
+// ImportState adopts a package already in the registry from an import ID of
+// the form `<upload_id>` or `<box>/<upload_id>`. Only what the registry
+// records is imported; the source, and everything derived from it, comes
+// from configuration and is filled in by the next apply, which reuses the
+// upload when the build reproduces its SHA256.
+func (r *LPKBuildResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	box := types.StringNull()
+	id := req.ID
+	if name, rest, ok := strings.Cut(req.ID, "/"); ok {
+		if name == "" || rest == "" {
+			resp.Diagnostics.AddError("Invalid import ID", fmt.Sprintf("Expected <upload_id> or <box>/<upload_id>, got %q", req.ID))
+			return
+		}
+		box, id = types.StringValue(name), rest
+	}
+	client := boxClient(r.client, box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	registry, err := resolvePublish(nil, client.PublishDefaults()).registryClient(client)
+	if err != nil {
+		resp.Diagnostics.AddError("Import failed", err.Error())
+		return
+	}
+	upload, err := registry.GetLPK(ctx, id)
+	if errors.Is(err, errNotFound) {
+		resp.Diagnostics.AddError("Upload not found", fmt.Sprintf("The registry has no upload %s.", id))
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to read upload %s, got error: %s", id, err))
+		return
+	}
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("box"), box)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("id"), upload.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("upload_id"), upload.ID)...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("lpk_url"), stringOrNull(upload.DownloadURL))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("sha256"), stringOrNull(upload.SHA256))...)
+	resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("version"), stringOrNull(upload.Version))...)
+	if upload.UID != "" && upload.UID != registry.UID() {
+		resp.Diagnostics.Append(resp.State.SetAttribute(ctx, path.Root("uid"), upload.UID)...)
+	}
+}
+
+// UpgradeState migrates state written by releases before schema versioning.
+func (r *LPKBuildResource) UpgradeState(ctx context.Context) map[int64]resource.StateUpgrader {
+	var current resource.SchemaResponse
+	r.Schema(ctx, resource.SchemaRequest{}, &current)
+	return map[int64]resource.StateUpgrader{
+		0: rawStateUpgrader(current.Schema),
+	}
+}
+
+func (r *LPKBuildResource) applyBuild(ctx context.Context, client Client, data *LPKBuildModel, prior *LPKBuildModel) (*LPKBuildModel, error) {
+	var stats buildStats
+	stage := time.Now()
+	workdir, source, err := r.prepareSource(ctx, data.Source)
+	if source != nil {
+		defer source.Cleanup()
+	}
 	if err != nil {
 		return nil, fmt.Errorf("source error: %w", err)
 	}
-	if cleanup != nil {
-		defer cleanup()
+	ext := resolveTemplateExtension(data.Env)
+	ignore, err := parseSourceIgnore(ctx, data.Source)
+	if err != nil {
+		return nil, err
 	}
-	fingerprint, err := hashDirectory(workdir)
+	fingerprint, err := source.Fingerprint(workdir, ext, ignore)
 	if err != nil {
 		return nil, fmt.Errorf("hash source: %w", err)
 	}
 	data.SourceHash = types.StringValue(fingerprint)
-	envVars := collectEnvVars(data.Env)
-	ext := resolveTemplateExtension(data.Env)
-	if err := renderTemplateFiles(workdir, ext, envVars); err != nil {
+	data.SourceRevision = types.StringNull()
+	if rev, ok := source.(revisionSource); ok && rev.Revision() != "" {
+		data.SourceRevision = types.StringValue(rev.Revision())
+	}
+	stats.clone = since(&stage)
+	retention, err := parseRetention(data.Retention)
+	if err != nil {
+		return nil, err
+	}
+	envVars, err := collectEnvVars(ctx, workdir, data.Env)
+	if err != nil {
+		return nil, err
+	}
+	ctx = maskSensitiveVars(ctx, data.Env)
+	checksums, err := checksumManifest(workdir, ext, ignore)
+	if err != nil {
+		return nil, fmt.Errorf("checksum source: %w", err)
+	}
+	checksumValue, diags := types.MapValueFrom(ctx, types.StringType, checksums)
+	if diags.HasError() {
+		return nil, errors.New("convert content checksums")
+	}
+	data.Checksums = checksumValue
+	artifactDir := workdir
+	if data.Source.Local != nil && !data.Source.Local.InPlace.ValueBool() {
+		workspace, err := stageWorkspace(workdir)
+		if workspace != "" {
+			defer os.RemoveAll(workspace)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("copy source: %w", err)
+		}
+		workdir = workspace
+	}
+	if err := renderTemplateFiles(workdir, ext, envVars, resolveEngine(data.Env), resolveOnMissing(data.Env)); err != nil {
 		return nil, err
 	}
-	lpkPath, meta, err := r.runBuild(ctx, workdir, data.Build, data.Publish, envVars)
+	manifestPath := filepath.Join(workdir, "lzc-manifest.yml")
+	restoreManifest, err := applyManifestOverride(manifestPath, data.ManifestOverride.ValueString())
 	if err != nil {
 		return nil, err
 	}
+	// Each restore function writes back the manifest as it was before its
+	// own edit, so only the first one is needed.
+	restoreEnv, err := injectServicesEnv(manifestPath, collectServicesEnv(data.Env))
+	if restoreManifest == nil {
+		restoreManifest = restoreEnv
+	}
+	if err != nil {
+		if restoreManifest != nil {
+			_ = restoreManifest()
+		}
+		return nil, fmt.Errorf("inject services_env: %w", err)
+	}
+	stats.render = since(&stage)
+	// The build may overwrite or the retention may prune the previous
+	// artifact, so the delta base is read before either runs.
+	var base []byte
+	if resolvePublish(data.Publish, client.PublishDefaults()).enabled {
+		base = deltaBase(prior)
+	}
+	lpkPath, meta, err := r.runBuild(ctx, workdir, artifactDir, data.Build, data.Publish, envVars, checksums)
+	if restoreManifest != nil {
+		if restoreErr := restoreManifest(); restoreErr != nil && err == nil {
+			err = fmt.Errorf("restore manifest: %w", restoreErr)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	stats.build = since(&stage)
+	stats.cacheHit = meta.CacheHit
+	if info, err := os.Stat(lpkPath); err == nil {
+		stats.artifactBytes = info.Size()
+	}
 	data.LocalPath = types.StringValue(lpkPath)
+	data.ManifestSHA = types.StringNull()
+	if _, manifestData, err := readLPKArchive(lpkPath); err == nil && manifestData != nil {
+		if _, sha, err := normalizeManifest(manifestData); err == nil {
+			data.ManifestSHA = types.StringValue(sha)
+		}
+	}
 	data.AppID = types.StringValue(meta.AppID)
 	data.Version = types.StringValue(meta.Version)
 	data.SHA256 = types.StringValue(meta.SHA256)
 	data.LPKURL = types.StringNull()
 	data.UploadID = types.StringNull()
-	if shouldPublish(data.Publish) {
-		if canReuseUpload(prior, meta) {
+	labels, diags := labelsMap(ctx, data.EffectiveLabels)
+	if diags.HasError() {
+		return nil, errors.New("convert effective_labels")
+	}
+	settings := resolvePublish(data.Publish, client.PublishDefaults())
+	if settings.enabled {
+		if canReuseUpload(prior, meta) && prior.UID.Equal(data.UID) && prior.EffectiveLabels.Equal(data.EffectiveLabels) && resolvePublish(prior.Publish, client.PublishDefaults()).registryURL == settings.registryURL {
 			data.LPKURL = prior.LPKURL
 			data.UploadID = prior.UploadID
 			if !prior.Version.IsNull() {
 				data.Version = prior.Version
 			}
 		} else {
+			registry, err := settings.registryClient(client)
+			if err != nil {
+				return nil, err
+			}
 			uploadName := meta.Name
-			if data.Publish != nil && !data.Publish.Name.IsNull() && data.Publish.Name.ValueString() != "" {
-				uploadName = data.Publish.Name.ValueString()
+			if data.Publish == nil || data.Publish.Name.ValueString() == "" {
+				uploadName = settings.namePrefix + meta.Name
 			}
 			uploadVersion := meta.Version
 			if data.Publish != nil && !data.Publish.Version.IsNull() && data.Publish.Version.ValueString() != "" {
 				uploadVersion = data.Publish.Version.ValueString()
 			}
-			upload, err := r.client.UploadLPK(ctx, r.client.User, uploadName, uploadVersion, lpkPath)
+			stage = time.Now()
+			upload, err := r.uploadArtifact(ctx, registry, prior, apiUploadRequest{
+				UID:       userClient(registry, data.UID).UID(),
+				Name:      uploadName,
+				Version:   uploadVersion,
+				Overwrite: settings.overwrite,
+				Labels:    labels,
+			}, base, lpkPath, meta.SHA256)
 			if err != nil {
 				return nil, fmt.Errorf("upload error: %w", err)
 			}
+			stats.upload = since(&stage)
 			data.LPKURL = types.StringValue(upload.DownloadURL)
 			data.UploadID = types.StringValue(upload.ID)
 			if upload.SHA256 != "" {
@@ -345,64 +967,105 @@ func (r *LPKBuildResource) applyBuild(ctx context.Context, data *LPKBuildModel,
 		}
 	}
 	data.ID = types.StringValue(fmt.Sprintf("%s-%s-%s", meta.AppID, meta.Version, meta.SHA256))
+	removed, err := pruneArtifacts(lpkPath, retention, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("prune artifacts: %w", err)
+	}
+	if len(removed) > 0 {
+		tflog.Info(ctx, "pruned old artifacts", map[string]any{"removed": removed})
+	}
+	statsValue, diags := stats.object(ctx)
+	if diags.HasError() {
+		return nil, errors.New("convert build stats")
+	}
+	data.Stats = statsValue
 	return data, nil
 }
 
-func (r *LPKBuildResource) prepareSource(ctx context.Context, source *LPKBuildSourceModel) (string, func(), error) {
-	if source == nil {
-		return "", nil, errors.New("source block is required")
-	}
-	if source.Local != nil {
-		if source.Local.Path.IsNull() || source.Local.Path.ValueString() == "" {
-			return "", nil, errors.New("local.path must be set")
+// uploadArtifact publishes lpkPath. When base holds the previously uploaded
+// artifact, a binary delta against it is uploaded instead; any failure of the
+// delta path falls back to a full upload.
+func (r *LPKBuildResource) uploadArtifact(ctx context.Context, client Client, prior *LPKBuildModel, request apiUploadRequest, base []byte, lpkPath, sha string) (*apiUploadLPKResponse, error) {
+	if base != nil {
+		upload, err := r.uploadDelta(ctx, client, prior, base, request, lpkPath, sha)
+		if err == nil {
+			return upload, nil
 		}
-		return source.Local.Path.ValueString(), nil, nil
+		tflog.Info(ctx, "delta upload unavailable, uploading full artifact", map[string]any{
+			"reason": err.Error(),
+		})
 	}
-	if source.Git != nil {
-		if source.Git.URL.IsNull() || source.Git.URL.ValueString() == "" {
-			return "", nil, errors.New("git.url must be set")
-		}
-		tmp, err := os.MkdirTemp("", "lpk-build-*")
-		if err != nil {
-			return "", nil, err
-		}
-		cleanup := func() { _ = os.RemoveAll(tmp) }
-		clone := exec.CommandContext(ctx, "git", "clone", source.Git.URL.ValueString(), "repo")
-		clone.Dir = tmp
-		clone.Stdout = os.Stdout
-		clone.Stderr = os.Stderr
-		if err := clone.Run(); err != nil {
-			cleanup()
-			return "", nil, fmt.Errorf("git clone failed: %w", err)
-		}
-		repoPath := filepath.Join(tmp, "repo")
-		if !source.Git.Ref.IsNull() && source.Git.Ref.ValueString() != "" {
-			checkout := exec.CommandContext(ctx, "git", "checkout", source.Git.Ref.ValueString())
-			checkout.Dir = repoPath
-			checkout.Stdout = os.Stdout
-			checkout.Stderr = os.Stderr
-			if err := checkout.Run(); err != nil {
-				cleanup()
-				return "", nil, fmt.Errorf("git checkout failed: %w", err)
-			}
-		}
-		sub := repoPath
-		if !source.Git.Subpath.IsNull() && source.Git.Subpath.ValueString() != "" {
-			sub = filepath.Join(repoPath, source.Git.Subpath.ValueString())
-		}
-		return sub, cleanup, nil
+	return client.UploadLPK(ctx, request, lpkPath)
+}
+
+func (r *LPKBuildResource) uploadDelta(ctx context.Context, client Client, prior *LPKBuildModel, base []byte, request apiUploadRequest, lpkPath, sha string) (*apiUploadLPKResponse, error) {
+	target, err := os.ReadFile(lpkPath)
+	if err != nil {
+		return nil, err
+	}
+	delta := computeDelta(base, target)
+	if len(delta) >= len(target) {
+		return nil, errors.New("delta is not smaller than the artifact")
+	}
+	upload, err := client.UploadLPKDelta(ctx, request, prior.UploadID.ValueString(), prior.SHA256.ValueString(), sha, delta)
+	if err != nil {
+		return nil, err
+	}
+	if upload.SHA256 != "" && upload.SHA256 != sha {
+		return nil, fmt.Errorf("registry reconstructed sha256 %s, expected %s", upload.SHA256, sha)
+	}
+	tflog.Debug(ctx, "uploaded artifact delta", map[string]any{
+		"delta_bytes":    len(delta),
+		"artifact_bytes": len(target),
+	})
+	return upload, nil
+}
+
+// deltaBase returns the contents of the artifact recorded in prior when it
+// is still present and matches the uploaded checksum, or nil otherwise.
+func deltaBase(prior *LPKBuildModel) []byte {
+	if prior == nil || prior.UploadID.ValueString() == "" || prior.SHA256.ValueString() == "" {
+		return nil
+	}
+	basePath := prior.LocalPath.ValueString()
+	if basePath == "" {
+		return nil
 	}
-	return "", nil, errors.New("either source.local or source.git must be provided")
+	base, err := os.ReadFile(basePath)
+	if err != nil {
+		return nil
+	}
+	sum := sha256.Sum256(base)
+	if hex.EncodeToString(sum[:]) != prior.SHA256.ValueString() {
+		return nil
+	}
+	return base
+}
+
+// prepareSource materializes source through its registered source provider.
+// The returned provider must be cleaned up by the caller even when an error is
+// returned.
+func (r *LPKBuildResource) prepareSource(ctx context.Context, source *LPKBuildSourceModel) (string, sourceProvider, error) {
+	provider, err := resolveSourceProvider(source)
+	if err != nil {
+		return "", nil, err
+	}
+	dir, err := provider.Prepare(ctx)
+	if err != nil {
+		return "", provider, err
+	}
+	return dir, provider, nil
 }
 
 type lpkMetadata struct {
-	AppID   string
-	Version string
-	SHA256  string
-	Name    string
+	AppID    string
+	Version  string
+	SHA256   string
+	Name     string
+	CacheHit bool
 }
 
-func (r *LPKBuildResource) runBuild(ctx context.Context, path string, build *LPKBuildBuildModel, pub *LPKBuildPublishModel, envVars map[string]string) (string, *lpkMetadata, error) {
+func (r *LPKBuildResource) runBuild(ctx context.Context, path, artifactDir string, build *LPKBuildBuildModel, pub *LPKBuildPublishModel, envVars map[string]string, checksums map[string]string) (string, *lpkMetadata, error) {
 	manifestPath := filepath.Join(path, "lzc-manifest.yml")
 	manifest, err := readManifest(manifestPath)
 	if err != nil {
@@ -419,8 +1082,15 @@ func (r *LPKBuildResource) runBuild(ctx context.Context, path string, build *LPK
 		return "", nil, fmt.Errorf("compute manifest hash: %w", err)
 	}
 	artifactBase := fmt.Sprintf("%s-%s-%s", manifest.Name, manifest.Version, manifestHash)
-	artifactPath := filepath.Join(path, artifactBase+".lpk")
-	if _, statErr := os.Stat(artifactPath); errors.Is(statErr, os.ErrNotExist) {
+	artifactPath, err := artifactLocation(artifactDir, build, artifactNameFields{Name: manifest.Name, Version: manifest.Version, SHA: manifestHash})
+	if err != nil {
+		return "", nil, err
+	}
+	_, statErr := os.Stat(artifactPath)
+	if statErr == nil && !artifactMatchesChecksums(artifactPath, checksums) {
+		statErr = os.ErrNotExist
+	}
+	if errors.Is(statErr, os.ErrNotExist) {
 		command := "npx lzc-cli project build ."
 		if build != nil && !build.Command.IsNull() && build.Command.ValueString() != "" {
 			command = build.Command.ValueString()
@@ -440,10 +1110,13 @@ func (r *LPKBuildResource) runBuild(ctx context.Context, path string, build *LPK
 			return "", nil, err
 		}
 		if out != artifactPath {
-			if err := os.Rename(out, artifactPath); err != nil {
-				return "", nil, fmt.Errorf("rename artifact: %w", err)
+			if err := moveArtifact(out, artifactPath); err != nil {
+				return "", nil, fmt.Errorf("move artifact: %w", err)
 			}
 		}
+		if err := writeChecksumManifest(artifactPath, checksums); err != nil {
+			return "", nil, fmt.Errorf("write checksum manifest: %w", err)
+		}
 	} else if statErr != nil {
 		return "", nil, fmt.Errorf("check artifact: %w", statErr)
 	}
@@ -452,10 +1125,11 @@ func (r *LPKBuildResource) runBuild(ctx context.Context, path string, build *LPK
 		return "", nil, err
 	}
 	meta := &lpkMetadata{
-		AppID:   manifest.AppID,
-		Version: manifest.Version,
-		SHA256:  sha,
-		Name:    artifactBase,
+		AppID:    manifest.AppID,
+		Version:  manifest.Version,
+		SHA256:   sha,
+		Name:     artifactBase,
+		CacheHit: statErr == nil,
 	}
 	if pub != nil && !pub.Version.IsNull() && pub.Version.ValueString() != "" {
 		meta.Version = pub.Version.ValueString()
@@ -466,30 +1140,88 @@ func (r *LPKBuildResource) runBuild(ctx context.Context, path string, build *LPK
 	return artifactPath, meta, nil
 }
 
-func collectEnvVars(env *LPKBuildEnvModel) map[string]string {
-	if env == nil || len(env.Variables) == 0 {
+func writeOnlyVersion(env *LPKBuildEnvModel) types.String {
+	if env == nil {
+		return types.StringNull()
+	}
+	return env.WriteOnlyVersion
+}
+
+// readWriteOnlyVars copies env.variables_wo from config onto data, as
+// Terraform leaves write-only values out of the plan. The framework drops
+// them again from the state data is saved to.
+func readWriteOnlyVars(ctx context.Context, config tfsdk.Config, data *LPKBuildModel) diag.Diagnostics {
+	if data.Env == nil {
 		return nil
 	}
-	values := make(map[string]string, len(env.Variables))
-	for key, value := range env.Variables {
-		if value.IsNull() || value.IsUnknown() {
-			continue
+	var vars map[string]types.String
+	diags := config.GetAttribute(ctx, path.Root("env").AtName("variables_wo"), &vars)
+	data.Env.WriteOnlyVars = vars
+	return diags
+}
+
+// maskSensitiveVars redacts the values of env.sensitive_variables and
+// env.variables_wo from everything logged through the returned context.
+func maskSensitiveVars(ctx context.Context, env *LPKBuildEnvModel) context.Context {
+	if env == nil {
+		return ctx
+	}
+	var secrets []string
+	for _, vars := range []map[string]types.String{env.SensitiveVars, env.WriteOnlyVars} {
+		for _, value := range vars {
+			if value.ValueString() != "" {
+				secrets = append(secrets, value.ValueString())
+			}
+		}
+	}
+	if len(secrets) == 0 {
+		return ctx
+	}
+	ctx = tflog.MaskMessageStrings(ctx, secrets...)
+	return tflog.MaskAllFieldValuesStrings(ctx, secrets...)
+}
+
+// collectEnvVars merges env.files, read from the source directory dir, with
+// the variables maps of env, which override them. env.defaults fills in
+// what none of them set.
+func collectEnvVars(ctx context.Context, dir string, env *LPKBuildEnvModel) (map[string]string, error) {
+	values, err := loadEnvFiles(ctx, dir, env)
+	if err != nil || env == nil {
+		return nil, err
+	}
+	if values == nil {
+		values = make(map[string]string, len(env.Variables)+len(env.SensitiveVars)+len(env.WriteOnlyVars))
+	}
+	for _, vars := range []map[string]types.String{env.Variables, env.SensitiveVars, env.WriteOnlyVars} {
+		for key, value := range vars {
+			if value.IsNull() || value.IsUnknown() {
+				continue
+			}
+			values[key] = value.ValueString()
+		}
+	}
+	for key, value := range env.Defaults {
+		if _, ok := values[key]; !ok && !value.IsNull() && !value.IsUnknown() {
+			values[key] = value.ValueString()
 		}
-		values[key] = value.ValueString()
 	}
 	if len(values) == 0 {
-		return nil
+		return nil, nil
 	}
-	return values
+	return values, nil
 }
 
 func resolveTemplateExtension(env *LPKBuildEnvModel) string {
+	fallback := defaultTemplateExtension
+	if resolveEngine(env) == engineJinja2 {
+		fallback = defaultJinjaExtension
+	}
 	if env == nil || env.TemplateExtension.IsNull() || env.TemplateExtension.IsUnknown() {
-		return defaultTemplateExtension
+		return fallback
 	}
 	ext := strings.TrimSpace(env.TemplateExtension.ValueString())
 	if ext == "" {
-		return defaultTemplateExtension
+		return fallback
 	}
 	if !strings.HasPrefix(ext, ".") {
 		ext = "." + ext
@@ -497,7 +1229,7 @@ func resolveTemplateExtension(env *LPKBuildEnvModel) string {
 	return ext
 }
 
-func renderTemplateFiles(baseDir, extension string, envVars map[string]string) error {
+func renderTemplateFiles(baseDir, extension string, envVars map[string]string, engine, onMissing string) error {
 	ext := extension
 	if ext == "" {
 		ext = defaultTemplateExtension
@@ -512,34 +1244,61 @@ func renderTemplateFiles(baseDir, extension string, envVars map[string]string) e
 		if !strings.HasSuffix(entry.Name(), ext) {
 			return nil
 		}
-		return renderTemplateFile(path, ext, envVars)
+		return renderTemplateFile(baseDir, path, ext, envVars, engine, onMissing)
 	})
 }
 
-func renderTemplateFile(path, extension string, envVars map[string]string) error {
+// renderTemplateFile renders the template at path next to it. Symlinks in
+// the source must not make either the template or its output resolve outside
+// baseDir, or a crafted repository could read or overwrite arbitrary files.
+func renderTemplateFile(baseDir, path, extension string, envVars map[string]string, engine, onMissing string) error {
+	if _, err := resolveWithin(baseDir, path); err != nil {
+		return fmt.Errorf("template %s: %w", path, err)
+	}
+	dest := strings.TrimSuffix(path, extension)
+	if _, err := os.Lstat(dest); err == nil {
+		if _, err := resolveWithin(baseDir, dest); err != nil {
+			return fmt.Errorf("rendered template %s: %w", dest, err)
+		}
+	}
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return fmt.Errorf("read template %s: %w", path, err)
 	}
-	tmpl, err := template.New(filepath.Base(path)).Option("missingkey=error").Parse(string(data))
+	rendered, err := renderTemplate(baseDir, path, string(data), envVars, engine, onMissing)
 	if err != nil {
-		return fmt.Errorf("parse template %s: %w", path, err)
-	}
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, envVars); err != nil {
-		return fmt.Errorf("render template %s: %w", path, formatTemplateError(err))
+		return fmt.Errorf("render template %s: %w", path, err)
 	}
-	dest := strings.TrimSuffix(path, extension)
 	perm := fs.FileMode(0o644)
 	if info, err := os.Stat(path); err == nil {
 		perm = info.Mode().Perm()
 	}
-	if err := os.WriteFile(dest, buf.Bytes(), perm); err != nil {
+	if err := os.WriteFile(dest, []byte(rendered), perm); err != nil {
 		return fmt.Errorf("write rendered template %s: %w", dest, err)
 	}
 	return nil
 }
 
+// renderTemplate renders the template at path within baseDir, whose
+// contents are text, with the engine.
+func renderTemplate(baseDir, path, text string, envVars map[string]string, engine, onMissing string) (string, error) {
+	if engine == engineJinja2 {
+		return renderJinja(baseDir, path, text, envVars, onMissing)
+	}
+	tmpl, err := template.New(filepath.Base(path)).Option(missingKeyOption(onMissing)).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	if onMissing == onMissingKeep {
+		envVars = keepMissing(tmpl, envVars)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, envVars); err != nil {
+		return "", formatTemplateError(err)
+	}
+	return buf.String(), nil
+}
+
 func formatTemplateError(err error) error {
 	var execErr *template.ExecError
 	if errors.As(err, &execErr) {
@@ -588,11 +1347,52 @@ func commandEnvironment(custom map[string]string) []string {
 	return env
 }
 
-func shouldPublish(pub *LPKBuildPublishModel) bool {
-	if pub == nil || pub.Enabled.IsNull() {
-		return true
+// publishSettings are the publish settings of a build after applying the
+// provider `default_publish` block.
+type publishSettings struct {
+	enabled     bool
+	registryURL string
+	namePrefix  string
+	overwrite   string
+	name        string
+	version     string
+}
+
+// resolvePublish merges pub over defaults; attributes set in pub win.
+func resolvePublish(pub *LPKBuildPublishModel, defaults publishDefaults) publishSettings {
+	settings := publishSettings{
+		enabled:     true,
+		registryURL: defaults.RegistryURL,
+		namePrefix:  defaults.NamePrefix,
+		overwrite:   defaults.Overwrite,
+	}
+	if defaults.Enabled != nil {
+		settings.enabled = *defaults.Enabled
+	}
+	if pub == nil {
+		return settings
 	}
-	return pub.Enabled.ValueBool()
+	if !pub.Enabled.IsNull() {
+		settings.enabled = pub.Enabled.ValueBool()
+	}
+	if v := pub.RegistryURL.ValueString(); v != "" {
+		settings.registryURL = v
+	}
+	if v := pub.Overwrite.ValueString(); v != "" {
+		settings.overwrite = v
+	}
+	settings.name = pub.Name.ValueString()
+	settings.version = pub.Version.ValueString()
+	return settings
+}
+
+// registryClient returns the client uploads go through: client itself, or
+// one for registryURL when a separate registry is configured.
+func (s publishSettings) registryClient(client Client) (Client, error) {
+	if s.registryURL == "" {
+		return client, nil
+	}
+	return client.ForRegistry(s.registryURL)
 }
 
 func findLatestLPK(dir string) (string, error) {
@@ -642,8 +1442,34 @@ func computeSHA(path string) (string, error) {
 	return hex.EncodeToString(hash.Sum(nil)), nil
 }
 
-func hashDirectory(root string) (string, error) {
+// hashDirectory returns a hash over the paths and contents of every file
+// checksumManifest covers beneath root, so any change to the source tree
+// outside ignore changes it.
+func hashDirectory(root, templateExt string, ignore sourceIgnore) (string, error) {
+	checksums, err := checksumManifest(root, templateExt, ignore)
+	if err != nil {
+		return "", err
+	}
+	keys := make([]string, 0, len(checksums))
+	for key := range checksums {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 	hash := sha256.New()
+	for _, key := range keys {
+		fmt.Fprintf(hash, "%s  %s\n", checksums[key], key)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+const checksumManifestSuffix = ".sha256sums"
+
+// checksumManifest returns the SHA256 of every regular file beneath root,
+// keyed by slash-separated relative path. VCS metadata, build artifacts,
+// files rendered from a sibling template, and paths matching ignore are
+// skipped so the result only reflects the declared source content.
+func checksumManifest(root, templateExt string, ignore sourceIgnore) (map[string]string, error) {
+	checksums := make(map[string]string)
 	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, walkErr error) error {
 		if walkErr != nil {
 			return walkErr
@@ -653,48 +1479,76 @@ func hashDirectory(root string) (string, error) {
 			return err
 		}
 		if entry.IsDir() {
-			if rel == ".git" || rel == ".terraform" {
+			if rel == ".git" || rel == ".terraform" || (rel != "." && ignore.matches(filepath.ToSlash(rel), true)) {
 				return filepath.SkipDir
 			}
 			return nil
 		}
-		if entry.Type()&os.ModeSymlink != 0 {
+		if !entry.Type().IsRegular() || ignore.matches(filepath.ToSlash(rel), false) {
 			return nil
 		}
-		if !shouldHashFile(entry.Name()) {
+		name := entry.Name()
+		if strings.HasSuffix(name, ".lpk") || strings.HasSuffix(name, checksumManifestSuffix) {
 			return nil
 		}
-		hash.Write([]byte(rel))
-		f, err := os.Open(path)
-		if err != nil {
-			return err
+		if _, err := os.Stat(path + templateExt); err == nil {
+			return nil
 		}
-		if _, err := io.Copy(hash, f); err != nil {
-			f.Close()
+		sum, err := computeSHA(path)
+		if err != nil {
 			return err
 		}
-		f.Close()
+		checksums[filepath.ToSlash(rel)] = sum
 		return nil
 	})
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return hex.EncodeToString(hash.Sum(nil)), nil
+	return checksums, nil
+}
+
+// writeChecksumManifest records checksums next to the artifact in
+// sha256sum format so a cached artifact can be tied back to its source.
+func writeChecksumManifest(artifactPath string, checksums map[string]string) error {
+	keys := make([]string, 0, len(checksums))
+	for key := range checksums {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var buf bytes.Buffer
+	for _, key := range keys {
+		fmt.Fprintf(&buf, "%s  %s\n", checksums[key], key)
+	}
+	return os.WriteFile(artifactPath+checksumManifestSuffix, buf.Bytes(), 0o644)
 }
 
-func shouldHashFile(name string) bool {
-	if strings.HasSuffix(name, ".tmpl") {
-		return true
+func readChecksumManifest(artifactPath string) (map[string]string, error) {
+	data, err := os.ReadFile(artifactPath + checksumManifestSuffix)
+	if err != nil {
+		return nil, err
 	}
-	if strings.HasSuffix(name, ".j2") || strings.HasSuffix(name, ".jinja") {
-		return true
+	checksums := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == "" {
+			continue
+		}
+		sum, rel, ok := strings.Cut(line, "  ")
+		if !ok {
+			return nil, fmt.Errorf("malformed checksum line %q", line)
+		}
+		checksums[rel] = sum
 	}
-	switch name {
-	case "manifest.yml", "lzc-manifest.yml", "lzc-build.yml":
-		return true
-	default:
+	return checksums, nil
+}
+
+// artifactMatchesChecksums reports whether the checksum manifest stored next
+// to artifactPath matches checksums.
+func artifactMatchesChecksums(artifactPath string, checksums map[string]string) bool {
+	recorded, err := readChecksumManifest(artifactPath)
+	if err != nil {
 		return false
 	}
+	return maps.Equal(recorded, checksums)
 }
 
 func canReuseUpload(prior *LPKBuildModel, meta *lpkMetadata) bool {
@@ -0,0 +1,361 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	_ "time/tzdata" // Embed zone data so window time zones resolve on any host.
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/listvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ resource.Resource = &MaintenanceWindowResource{}
+var _ resource.ResourceWithImportState = &MaintenanceWindowResource{}
+var _ resource.ResourceWithValidateConfig = &MaintenanceWindowResource{}
+
+type MaintenanceWindowResource struct {
+	client Client
+}
+
+type MaintenanceWindowModel struct {
+	ID       types.String `tfsdk:"id"`
+	Box      types.String `tfsdk:"box"`
+	Name     types.String `tfsdk:"name"`
+	Days     types.List   `tfsdk:"days"`
+	Start    types.String `tfsdk:"start"`
+	Duration types.String `tfsdk:"duration"`
+	Timezone types.String `tfsdk:"timezone"`
+}
+
+var windowDays = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+var windowStartPattern = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]$`)
+
+func NewMaintenanceWindowResource() resource.Resource {
+	return &MaintenanceWindowResource{}
+}
+
+func (r *MaintenanceWindowResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_maintenance_window"
+}
+
+func (r *MaintenanceWindowResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Registers a maintenance window on the NAS. Once any window exists, the provider only creates, updates, or deletes other resources while a window is open; outside them applies fail with a scheduling error. Windows themselves can be managed at any time.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Window identifier assigned by the NAS.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"box": boxAttribute(),
+			"name": schema.StringAttribute{
+				Required:    true,
+				Description: "Human readable name shown in scheduling errors.",
+			},
+			"days": schema.ListAttribute{
+				Optional:    true,
+				ElementType: types.StringType,
+				Description: "Days the window opens on, as sun, mon, tue, wed, thu, fri, or sat. Defaults to every day.",
+				Validators: []validator.List{
+					listvalidator.ValueStringsAre(stringvalidator.OneOf(windowDays...)),
+				},
+			},
+			"start": schema.StringAttribute{
+				Required:    true,
+				Description: "Local time the window opens, as HH:MM.",
+				Validators: []validator.String{
+					stringvalidator.RegexMatches(windowStartPattern, "must be a 24-hour time such as 02:30"),
+				},
+			},
+			"duration": schema.StringAttribute{
+				Required:    true,
+				Description: "How long the window stays open as a Go duration, e.g. 2h, up to 24h.",
+			},
+			"timezone": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("UTC"),
+				Description: "IANA time zone start is expressed in. Defaults to UTC.",
+			},
+		},
+	}
+}
+
+func (r *MaintenanceWindowResource) ValidateConfig(ctx context.Context, req resource.ValidateConfigRequest, resp *resource.ValidateConfigResponse) {
+	var data MaintenanceWindowModel
+	resp.Diagnostics.Append(req.Config.Get(ctx, &data)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if !data.Duration.IsNull() && !data.Duration.IsUnknown() {
+		if _, err := windowDuration(data.Duration.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("duration"), "Invalid duration", err.Error())
+		}
+	}
+	if !data.Timezone.IsNull() && !data.Timezone.IsUnknown() {
+		if _, err := time.LoadLocation(data.Timezone.ValueString()); err != nil {
+			resp.Diagnostics.AddAttributeError(path.Root("timezone"), "Invalid timezone", err.Error())
+		}
+	}
+}
+
+func (r *MaintenanceWindowResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	client, ok := req.ProviderData.(Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected Client, got %T", req.ProviderData))
+		return
+	}
+	r.client = client
+}
+
+func (r *MaintenanceWindowResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if !ensureNotReadOnly(r.client, &resp.Diagnostics) {
+		return
+	}
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var plan MaintenanceWindowModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, plan.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	window, err := r.windowRequest(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid days", err.Error())
+		return
+	}
+	created, err := client.SetMaintenanceWindow(ctx, window)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to create maintenance window, got error: %s", err))
+		return
+	}
+	resp.Diagnostics.Append(applyMaintenanceWindow(ctx, &plan, created)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *MaintenanceWindowResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var state MaintenanceWindowModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, state.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	window, err := client.GetMaintenanceWindow(ctx, state.ID.ValueString())
+	if errors.Is(err, errNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Read maintenance window failed", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(applyMaintenanceWindow(ctx, &state, window)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *MaintenanceWindowResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if !ensureNotReadOnly(r.client, &resp.Diagnostics) {
+		return
+	}
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var plan, state MaintenanceWindowModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, plan.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	plan.ID = state.ID
+	window, err := r.windowRequest(ctx, &plan)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid days", err.Error())
+		return
+	}
+	updated, err := client.SetMaintenanceWindow(ctx, window)
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update maintenance window, got error: %s", err))
+		return
+	}
+	resp.Diagnostics.Append(applyMaintenanceWindow(ctx, &plan, updated)...)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *MaintenanceWindowResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if !ensureNotReadOnly(r.client, &resp.Diagnostics) {
+		return
+	}
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var state MaintenanceWindowModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, state.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if err := client.DeleteMaintenanceWindow(ctx, state.ID.ValueString()); err != nil && !errors.Is(err, errNotFound) {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete maintenance window, got error: %s", err))
+	}
+}
+
+func (r *MaintenanceWindowResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	importStateWithBox(ctx, req, resp)
+}
+
+func (r *MaintenanceWindowResource) windowRequest(ctx context.Context, data *MaintenanceWindowModel) (apiMaintenanceWindow, error) {
+	window := apiMaintenanceWindow{
+		ID:       data.ID.ValueString(),
+		Name:     data.Name.ValueString(),
+		Start:    data.Start.ValueString(),
+		Duration: data.Duration.ValueString(),
+		Timezone: data.Timezone.ValueString(),
+	}
+	if !data.Days.IsNull() && !data.Days.IsUnknown() {
+		if diags := data.Days.ElementsAs(ctx, &window.Days, false); diags.HasError() {
+			return window, errors.New("days must be a list of strings")
+		}
+	}
+	return window, nil
+}
+
+func applyMaintenanceWindow(ctx context.Context, data *MaintenanceWindowModel, window *apiMaintenanceWindow) diag.Diagnostics {
+	var diags diag.Diagnostics
+	data.ID = types.StringValue(window.ID)
+	data.Name = types.StringValue(window.Name)
+	data.Start = types.StringValue(window.Start)
+	if window.Duration != "" && !sameDuration(data.Duration.ValueString(), window.Duration) {
+		data.Duration = types.StringValue(window.Duration)
+	}
+	if window.Timezone != "" {
+		data.Timezone = types.StringValue(window.Timezone)
+	}
+	if len(window.Days) > 0 {
+		data.Days, diags = types.ListValueFrom(ctx, types.StringType, window.Days)
+	} else if !data.Days.IsNull() {
+		data.Days = types.ListNull(types.StringType)
+	}
+	return diags
+}
+
+// sameDuration reports whether a and b spell the same duration, so "2h" in
+// configuration does not drift against a NAS that reports "2h0m0s".
+func sameDuration(a, b string) bool {
+	da, errA := time.ParseDuration(a)
+	db, errB := time.ParseDuration(b)
+	return errA == nil && errB == nil && da == db
+}
+
+func windowDuration(value string) (time.Duration, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 || d > 24*time.Hour {
+		return 0, fmt.Errorf("duration must be a positive Go duration of at most 24h, got %q", value)
+	}
+	return d, nil
+}
+
+// windowOpen reports whether window is open at now. A window that starts late
+// in the day may still be open early the next day, so the previous day's start
+// is checked as well.
+func windowOpen(window apiMaintenanceWindow, now time.Time) (bool, error) {
+	loc := time.UTC
+	if window.Timezone != "" {
+		var err error
+		if loc, err = time.LoadLocation(window.Timezone); err != nil {
+			return false, err
+		}
+	}
+	start, err := time.Parse("15:04", window.Start)
+	if err != nil {
+		return false, err
+	}
+	duration, err := windowDuration(window.Duration)
+	if err != nil {
+		return false, err
+	}
+	local := now.In(loc)
+	for _, offset := range []int{0, -1} {
+		day := local.AddDate(0, 0, offset)
+		opens := time.Date(day.Year(), day.Month(), day.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+		if !windowOnDay(window.Days, opens.Weekday()) {
+			continue
+		}
+		if !local.Before(opens) && local.Before(opens.Add(duration)) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func windowOnDay(days []string, weekday time.Weekday) bool {
+	if len(days) == 0 {
+		return true
+	}
+	for _, day := range days {
+		if strings.EqualFold(day, windowDays[weekday]) {
+			return true
+		}
+	}
+	return false
+}
+
+// describeWindows renders windows for scheduling diagnostics, e.g.
+// "nightly (daily 02:00 for 2h UTC)".
+func describeWindows(windows []apiMaintenanceWindow) string {
+	parts := make([]string, 0, len(windows))
+	for _, window := range windows {
+		days := "daily"
+		if len(window.Days) > 0 {
+			days = strings.Join(window.Days, ",")
+		}
+		tz := window.Timezone
+		if tz == "" {
+			tz = "UTC"
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s %s for %s %s)", window.Name, days, window.Start, window.Duration, tz))
+	}
+	return strings.Join(parts, "; ")
+}
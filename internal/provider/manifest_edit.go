@@ -0,0 +1,255 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// collectServicesEnv flattens the configured services_env map, dropping
+// null or unknown values so that only concrete entries reach the manifest.
+func collectServicesEnv(env *LPKBuildEnvModel) map[string]map[string]string {
+	if env == nil || len(env.ServicesEnv) == 0 {
+		return nil
+	}
+	out := make(map[string]map[string]string, len(env.ServicesEnv))
+	for service, vars := range env.ServicesEnv {
+		values := make(map[string]string, len(vars))
+		for key, value := range vars {
+			if value.IsNull() || value.IsUnknown() {
+				continue
+			}
+			values[key] = value.ValueString()
+		}
+		if len(values) > 0 {
+			out[service] = values
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// injectServicesEnv merges servicesEnv into the environment section of each
+// named service in the manifest at manifestPath. It returns a restore function
+// that writes the original manifest back so the source tree is left as found.
+func injectServicesEnv(manifestPath string, servicesEnv map[string]map[string]string) (func() error, error) {
+	if len(servicesEnv) == 0 {
+		return nil, nil
+	}
+	return editManifest(manifestPath, func(root *yaml.Node) error {
+		services := mappingValue(root, "services")
+		if services == nil || services.Kind != yaml.MappingNode {
+			return fmt.Errorf("services_env is set but manifest %s declares no services", manifestPath)
+		}
+		names := make([]string, 0, len(servicesEnv))
+		for name := range servicesEnv {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			service := mappingValue(services, name)
+			if service == nil || service.Kind != yaml.MappingNode {
+				return fmt.Errorf("service %q referenced in services_env is not declared in the manifest", name)
+			}
+			if err := mergeServiceEnvironment(service, servicesEnv[name]); err != nil {
+				return fmt.Errorf("service %q: %w", name, err)
+			}
+		}
+		return nil
+	})
+}
+
+// applyManifestOverride deep-merges the JSON object override onto the
+// manifest at manifestPath: nested mappings are merged key by key, any other
+// value replaces the one in the manifest, and null removes the key. It
+// returns a restore function like injectServicesEnv.
+func applyManifestOverride(manifestPath, override string) (func() error, error) {
+	if override == "" {
+		return nil, nil
+	}
+	var patch yaml.Node
+	if err := yaml.Unmarshal([]byte(override), &patch); err != nil {
+		return nil, fmt.Errorf("parse manifest_override: %w", err)
+	}
+	if patch.Kind != yaml.DocumentNode || len(patch.Content) == 0 || patch.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("manifest_override must be a JSON object")
+	}
+	blockStyle(&patch)
+	return editManifest(manifestPath, func(root *yaml.Node) error {
+		mergeManifestNode(root, patch.Content[0])
+		return nil
+	})
+}
+
+// mergeManifestNode merges the mapping src into the mapping dst.
+func mergeManifestNode(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		index := -1
+		for j := 0; j+1 < len(dst.Content); j += 2 {
+			if dst.Content[j].Value == key.Value {
+				index = j
+				break
+			}
+		}
+		switch {
+		case value.Tag == "!!null":
+			if index >= 0 {
+				dst.Content = append(dst.Content[:index], dst.Content[index+2:]...)
+			}
+		case index < 0:
+			dst.Content = append(dst.Content, key, value)
+		case value.Kind == yaml.MappingNode && dst.Content[index+1].Kind == yaml.MappingNode:
+			mergeManifestNode(dst.Content[index+1], value)
+		default:
+			dst.Content[index+1] = value
+		}
+	}
+}
+
+// blockStyle drops the JSON flow and quoting styles from node so merged
+// values are written like the rest of the manifest.
+func blockStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+// editManifest applies edit to the root mapping of the manifest at
+// manifestPath and writes the result back. The returned function restores
+// the original file.
+func editManifest(manifestPath string, edit func(root *yaml.Node) error) (func() error, error) {
+	original, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("read manifest: %w", err)
+	}
+	perm := fs.FileMode(0o644)
+	if info, err := os.Stat(manifestPath); err == nil {
+		perm = info.Mode().Perm()
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(original, &doc); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("manifest %s is not a YAML mapping", manifestPath)
+	}
+	if err := edit(doc.Content[0]); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	if err := os.WriteFile(manifestPath, buf.Bytes(), perm); err != nil {
+		return nil, fmt.Errorf("write manifest: %w", err)
+	}
+	restore := func() error {
+		return os.WriteFile(manifestPath, original, perm)
+	}
+	return restore, nil
+}
+
+// mergeServiceEnvironment updates the environment of a single service. Both
+// the list form ("KEY=value") and the mapping form are supported; keys that
+// are not yet present are appended in sorted order for deterministic output.
+func mergeServiceEnvironment(service *yaml.Node, values map[string]string) error {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	env := mappingValue(service, "environment")
+	if env == nil {
+		env = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		service.Content = append(service.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "environment"},
+			env,
+		)
+	}
+	switch env.Kind {
+	case yaml.SequenceNode:
+		for _, key := range keys {
+			entry := key + "=" + values[key]
+			replaced := false
+			for _, item := range env.Content {
+				if item.Kind != yaml.ScalarNode {
+					continue
+				}
+				if name, _, _ := strings.Cut(item.Value, "="); name == key {
+					item.Value = entry
+					item.Tag = "!!str"
+					item.Style = 0
+					replaced = true
+				}
+			}
+			if !replaced {
+				env.Content = append(env.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: entry})
+			}
+		}
+	case yaml.MappingNode:
+		for _, key := range keys {
+			if existing := mappingValue(env, key); existing != nil {
+				existing.Kind = yaml.ScalarNode
+				existing.Tag = "!!str"
+				existing.Value = values[key]
+				existing.Style = 0
+				existing.Content = nil
+				continue
+			}
+			env.Content = append(env.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: values[key]},
+			)
+		}
+	default:
+		return fmt.Errorf("environment must be a list or a mapping")
+	}
+	return nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// normalizeManifest renders a YAML or JSON manifest as compact JSON with
+// sorted keys, so manifests that differ only in formatting compare equal.
+// It returns the normalized document and its SHA256.
+func normalizeManifest(data []byte) (string, string, error) {
+	var doc any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return "", "", err
+	}
+	normalized, err := json.Marshal(doc)
+	if err != nil {
+		return "", "", err
+	}
+	sum := sha256.Sum256(normalized)
+	return string(normalized), hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,209 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+
+	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v3"
+)
+
+var _ function.Function = &ParseLPKFunction{}
+
+type ParseLPKFunction struct{}
+
+type parseLPKResult struct {
+	AppID   types.String `tfsdk:"appid"`
+	Name    types.String `tfsdk:"name"`
+	Version types.String `tfsdk:"version"`
+	SHA256  types.String `tfsdk:"sha256"`
+	Files   []string     `tfsdk:"files"`
+}
+
+// lpkManifestNames lists the archive entries that may hold the package
+// manifest, in order of preference.
+var lpkManifestNames = []string{"manifest.yml", "lzc-manifest.yml"}
+
+func NewParseLPKFunction() function.Function {
+	return &ParseLPKFunction{}
+}
+
+func (f *ParseLPKFunction) Metadata(_ context.Context, _ function.MetadataRequest, resp *function.MetadataResponse) {
+	resp.Name = "parse_lpk"
+}
+
+func (f *ParseLPKFunction) Definition(_ context.Context, _ function.DefinitionRequest, resp *function.DefinitionResponse) {
+	resp.Definition = function.Definition{
+		Summary:     "Inspect a local LPK archive",
+		Description: "Opens a local .lpk archive and returns its manifest fields, checksum, and the sorted list of files it contains.",
+		Parameters: []function.Parameter{
+			function.StringParameter{
+				Name:        "path",
+				Description: "Path to the .lpk archive on the machine running Terraform.",
+			},
+		},
+		Return: function.ObjectReturn{
+			AttributeTypes: map[string]attr.Type{
+				"appid":   types.StringType,
+				"name":    types.StringType,
+				"version": types.StringType,
+				"sha256":  types.StringType,
+				"files":   types.ListType{ElemType: types.StringType},
+			},
+		},
+	}
+}
+
+func (f *ParseLPKFunction) Run(ctx context.Context, req function.RunRequest, resp *function.RunResponse) {
+	var lpkPath string
+	resp.Error = function.ConcatFuncErrors(req.Arguments.Get(ctx, &lpkPath))
+	if resp.Error != nil {
+		return
+	}
+	files, manifestData, err := readLPKArchive(lpkPath)
+	if err != nil {
+		resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Unable to read LPK %s: %s", lpkPath, err))
+		return
+	}
+	var manifest manifestYAML
+	if manifestData != nil {
+		if err := yaml.Unmarshal(manifestData, &manifest); err != nil {
+			resp.Error = function.NewArgumentFuncError(0, fmt.Sprintf("Unable to parse manifest in %s: %s", lpkPath, err))
+			return
+		}
+	}
+	sha, err := computeSHA(lpkPath)
+	if err != nil {
+		resp.Error = function.NewFuncError(err.Error())
+		return
+	}
+	result := parseLPKResult{
+		AppID:   stringOrNull(manifest.AppID),
+		Name:    stringOrNull(manifest.Name),
+		Version: stringOrNull(manifest.Version),
+		SHA256:  types.StringValue(sha),
+		Files:   files,
+	}
+	resp.Error = function.ConcatFuncErrors(resp.Result.Set(ctx, &result))
+}
+
+// readLPKArchive lists the files of a zip or (optionally gzipped) tar LPK and
+// returns the contents of its manifest, if present.
+func readLPKArchive(lpkPath string) ([]string, []byte, error) {
+	f, err := os.Open(lpkPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(f, header); err != nil {
+		return nil, nil, fmt.Errorf("read header: %w", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, nil, err
+	}
+	if bytes.Equal(header, []byte("PK\x03\x04")) {
+		return readLPKZip(f, info.Size())
+	}
+	return readLPKTar(f)
+}
+
+func readLPKZip(r io.ReaderAt, size int64) ([]string, []byte, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, err
+	}
+	files := []string{}
+	entries := make(map[string]*zip.File)
+	for _, entry := range archive.File {
+		if entry.FileInfo().IsDir() {
+			continue
+		}
+		name := path.Clean(entry.Name)
+		files = append(files, name)
+		entries[name] = entry
+	}
+	sort.Strings(files)
+	for _, name := range lpkManifestNames {
+		entry, ok := entries[name]
+		if !ok {
+			continue
+		}
+		rc, err := entry.Open()
+		if err != nil {
+			return nil, nil, err
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, nil, err
+		}
+		return files, data, nil
+	}
+	return files, nil, nil
+}
+
+func readLPKTar(r io.Reader) ([]string, []byte, error) {
+	buffered := bufio.NewReader(r)
+	var source io.Reader = buffered
+	if magic, err := buffered.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer gz.Close()
+		source = gz
+	}
+	tr := tar.NewReader(source)
+	files := []string{}
+	manifests := make(map[string][]byte)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("not a zip or tar archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		name := path.Clean(header.Name)
+		files = append(files, name)
+		for _, manifestName := range lpkManifestNames {
+			if name != manifestName {
+				continue
+			}
+			data, err := io.ReadAll(tr)
+			if err != nil {
+				return nil, nil, err
+			}
+			manifests[name] = data
+		}
+	}
+	sort.Strings(files)
+	for _, name := range lpkManifestNames {
+		if data, ok := manifests[name]; ok {
+			return files, data, nil
+		}
+	}
+	return files, nil, nil
+}
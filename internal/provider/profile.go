@@ -0,0 +1,102 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// defaultProfile is read from the shared credentials file when no profile is
+// configured.
+const defaultProfile = "default"
+
+// lcmdProfile holds the connection settings of one shared credentials file
+// profile.
+type lcmdProfile struct {
+	Endpoint string
+	User     string
+	APIToken string
+}
+
+// defaultCredentialsFile returns ~/.lzc/credentials.
+func defaultCredentialsFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".lzc", "credentials"), nil
+}
+
+// loadProfile reads the named profile from an INI style shared credentials
+// file:
+//
+//	[default]
+//	endpoint  = https://nas.example.com
+//	user      = admin
+//	api_token = ...
+//
+// When required is false, a missing file or profile yields an empty profile
+// instead of an error, so the default profile is only used when present.
+func loadProfile(file, name string, required bool) (lcmdProfile, error) {
+	contents, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) && !required {
+		return lcmdProfile{}, nil
+	}
+	if err != nil {
+		return lcmdProfile{}, fmt.Errorf("read shared credentials file: %w", err)
+	}
+	profiles, err := parseProfiles(contents)
+	if err != nil {
+		return lcmdProfile{}, fmt.Errorf("parse %s: %w", file, err)
+	}
+	profile, ok := profiles[name]
+	if !ok && required {
+		return lcmdProfile{}, fmt.Errorf("profile %q not found in %s", name, file)
+	}
+	return profile, nil
+}
+
+func parseProfiles(contents []byte) (map[string]lcmdProfile, error) {
+	profiles := map[string]lcmdProfile{}
+	section := ""
+	scanner := bufio.NewScanner(bytes.NewReader(contents))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") || strings.HasPrefix(text, ";") {
+			continue
+		}
+		if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+			section = strings.TrimSpace(text[1 : len(text)-1])
+			profiles[section] = profiles[section]
+			continue
+		}
+		key, value, ok := strings.Cut(text, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected key = value", line)
+		}
+		if section == "" {
+			return nil, fmt.Errorf("line %d: %s is outside a [profile] section", line, strings.TrimSpace(key))
+		}
+		profile := profiles[section]
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "endpoint":
+			profile.Endpoint = value
+		case "user":
+			profile.User = value
+		case "api_token":
+			profile.APIToken = value
+		default:
+			return nil, fmt.Errorf("line %d: unknown key %q", line, strings.TrimSpace(key))
+		}
+		profiles[section] = profile
+	}
+	return profiles, scanner.Err()
+}
@@ -5,15 +5,27 @@ package provider
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"os"
 	"slices"
+	"strings"
+	"time"
 
+	"github.com/hashicorp/terraform-plugin-framework-validators/float64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/objectvalidator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/ephemeral"
 	"github.com/hashicorp/terraform-plugin-framework/function"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
 	"github.com/hashicorp/terraform-plugin-framework/types"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 )
@@ -33,8 +45,52 @@ type LcmdProvider struct {
 
 // LcmdProviderModel describes the provider data model.
 type LcmdProviderModel struct {
+	Endpoint           types.String            `tfsdk:"endpoint"`
+	User               types.String            `tfsdk:"user"`
+	APIToken           types.String            `tfsdk:"api_token"`
+	Profile            types.String            `tfsdk:"profile"`
+	CredentialsFile    types.String            `tfsdk:"shared_credentials_file"`
+	CAFile             types.String            `tfsdk:"ca_file"`
+	CAPEM              types.String            `tfsdk:"ca_pem"`
+	Insecure           types.Bool              `tfsdk:"insecure"`
+	RequestTimeout     types.String            `tfsdk:"request_timeout"`
+	UploadTimeout      types.String            `tfsdk:"upload_timeout"`
+	MaxRetries         types.Int64             `tfsdk:"max_retries"`
+	RetryBackoff       types.String            `tfsdk:"retry_backoff"`
+	ReadOnly           types.Bool              `tfsdk:"read_only"`
+	SkipUserValidation types.Bool              `tfsdk:"skip_user_validation"`
+	MinAPIVersion      types.Int64             `tfsdk:"min_api_version"`
+	DebugHTTP          types.Bool              `tfsdk:"debug_http"`
+	MaxConcurrent      types.Int64             `tfsdk:"max_concurrent_requests"`
+	RequestsPerSecond  types.Float64           `tfsdk:"requests_per_second"`
+	CredentialsSource  *CredentialsSourceModel `tfsdk:"credentials_source"`
+	CredentialHelper   types.String            `tfsdk:"credential_helper"`
+	ProxyURL           types.String            `tfsdk:"proxy_url"`
+	ProxyUsername      types.String            `tfsdk:"proxy_username"`
+	ProxyPassword      types.String            `tfsdk:"proxy_password"`
+	SSH                *SSHModel               `tfsdk:"ssh"`
+	Boxes              map[string]BoxModel     `tfsdk:"boxes"`
+	DefaultPublish     *DefaultPublishModel    `tfsdk:"default_publish"`
+	DefaultLabels      types.Map               `tfsdk:"default_labels"`
+}
+
+// BoxModel describes an additional named NAS endpoint.
+type BoxModel struct {
 	Endpoint types.String `tfsdk:"endpoint"`
 	User     types.String `tfsdk:"user"`
+	APIToken types.String `tfsdk:"api_token"`
+}
+
+// SSHModel configures the SSH tunnel used to reach the NAS API.
+type SSHModel struct {
+	Host           types.String `tfsdk:"host"`
+	Port           types.Int64  `tfsdk:"port"`
+	User           types.String `tfsdk:"user"`
+	PrivateKey     types.String `tfsdk:"private_key"`
+	PrivateKeyFile types.String `tfsdk:"private_key_file"`
+	KnownHostsFile types.String `tfsdk:"known_hosts_file"`
+	RemoteHost     types.String `tfsdk:"remote_host"`
+	RemotePort     types.Int64  `tfsdk:"remote_port"`
 }
 
 func (p *LcmdProvider) Metadata(ctx context.Context, req provider.MetadataRequest, resp *provider.MetadataResponse) {
@@ -45,14 +101,225 @@ func (p *LcmdProvider) Metadata(ctx context.Context, req provider.MetadataReques
 func (p *LcmdProvider) Schema(ctx context.Context, req provider.SchemaRequest, resp *provider.SchemaResponse) {
 	resp.Schema = schema.Schema{
 		Attributes: map[string]schema.Attribute{
+			"debug_http": schema.BoolAttribute{
+				MarkdownDescription: "Log every API request and response (method, path, status, duration, and a truncated body) at debug level. Authorization headers, tokens, passwords, and environment variable values are redacted. View the output with `TF_LOG=DEBUG`.",
+				Optional:            true,
+			},
 			"endpoint": schema.StringAttribute{
-				MarkdownDescription: "Base URL of the NAS API",
-				Required:            true,
+				MarkdownDescription: "Base URL of the NAS API. Use `unix:///path/to.sock` to reach the API over a local unix domain socket. May also be set with the `LCMD_ENDPOINT` environment variable.",
+				Optional:            true,
 			},
 			"user": schema.StringAttribute{
-				MarkdownDescription: "LZC UID that owns the applications",
-				Required:            true,
+				MarkdownDescription: "LZC UID that owns the applications. May also be set with the `LCMD_USER` environment variable. Optional when the NAS has a single user, which is then selected automatically.",
+				Optional:            true,
+			},
+			"profile": schema.StringAttribute{
+				MarkdownDescription: "Profile of the shared credentials file to read `endpoint`, `user`, and `api_token` from when they are not set in the provider block or the environment. May also be set with the `LCMD_PROFILE` environment variable. Defaults to `default`, which is only used when present.",
+				Optional:            true,
+			},
+			"shared_credentials_file": schema.StringAttribute{
+				MarkdownDescription: "Path of the shared credentials file. May also be set with the `LCMD_SHARED_CREDENTIALS_FILE` environment variable. Defaults to `~/.lzc/credentials`.",
+				Optional:            true,
+			},
+			"api_token": schema.StringAttribute{
+				MarkdownDescription: "Token sent as an `Authorization: Bearer` header on every API request. May also be set with the `LCMD_API_TOKEN` environment variable.",
+				Optional:            true,
+				Sensitive:           true,
+			},
+			"boxes": schema.MapNestedAttribute{
+				MarkdownDescription: "Additional NAS endpoints keyed by name, so one configuration can manage several boxes. Resources select one with their `box` attribute and use `endpoint` otherwise. Boxes share the TLS, timeout, retry, and proxy settings of the provider, and cannot be combined with `ssh`.",
+				Optional:            true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"endpoint": schema.StringAttribute{
+							MarkdownDescription: "Base URL of the box API.",
+							Required:            true,
+						},
+						"user": schema.StringAttribute{
+							MarkdownDescription: "LZC UID that owns the applications on this box. Optional when the box has a single user.",
+							Optional:            true,
+						},
+						"api_token": schema.StringAttribute{
+							MarkdownDescription: "Token sent as an `Authorization: Bearer` header on every request to this box.",
+							Optional:            true,
+							Sensitive:           true,
+						},
+					},
+				},
+			},
+			"credential_helper": schema.StringAttribute{
+				MarkdownDescription: "Program that supplies the API token using the git credential helper protocol, e.g. `git-credential-libsecret` or `git-credential-osxkeychain` to read it from the OS keychain. It is run with the argument `get`, receives `protocol`, `host`, `path`, and `username` of the endpoint on standard input, and must print a `password=` line. Programs not given as a path are looked up with a `git-credential-` prefix first, like git does.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("api_token"), path.MatchRoot("credentials_source")),
+				},
+			},
+			"credentials_source": schema.SingleNestedAttribute{
+				MarkdownDescription: "Read the API token from a file, an environment variable, or the standard output of a command such as a secrets manager CLI, instead of setting `api_token`. Exactly one source must be set.",
+				Optional:            true,
+				Validators: []validator.Object{
+					objectvalidator.ConflictsWith(path.MatchRoot("api_token")),
+				},
+				Attributes: map[string]schema.Attribute{
+					"file": schema.StringAttribute{
+						MarkdownDescription: "Path to a file containing the token.",
+						Optional:            true,
+						Validators: []validator.String{
+							stringvalidator.ExactlyOneOf(
+								path.MatchRelative().AtParent().AtName("file"),
+								path.MatchRelative().AtParent().AtName("env"),
+								path.MatchRelative().AtParent().AtName("command"),
+							),
+						},
+					},
+					"env": schema.StringAttribute{
+						MarkdownDescription: "Name of the environment variable holding the token.",
+						Optional:            true,
+					},
+					"command": schema.StringAttribute{
+						MarkdownDescription: "Shell command whose standard output is the token, e.g. `op read op://nas/lcmd/token`.",
+						Optional:            true,
+					},
+				},
+			},
+			"ca_file": schema.StringAttribute{
+				MarkdownDescription: "Path to a PEM encoded CA bundle used to verify the NAS certificate, e.g. for a self-signed local domain.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.ConflictsWith(path.MatchRoot("ca_pem")),
+				},
+			},
+			"ca_pem": schema.StringAttribute{
+				MarkdownDescription: "PEM encoded CA bundle used to verify the NAS certificate.",
+				Optional:            true,
+			},
+			"insecure": schema.BoolAttribute{
+				MarkdownDescription: "Skip TLS certificate verification. Only use this for testing.",
+				Optional:            true,
+			},
+			"request_timeout": schema.StringAttribute{
+				MarkdownDescription: "Timeout for metadata API calls as a Go duration, e.g. `30s`. Defaults to `30s`.",
+				Optional:            true,
+			},
+			"upload_timeout": schema.StringAttribute{
+				MarkdownDescription: "Timeout for LPK uploads and `lcmd_file_download` transfers as a Go duration. Defaults to `10m`.",
+				Optional:            true,
+			},
+			"max_retries": schema.Int64Attribute{
+				MarkdownDescription: "Number of times idempotent requests (GET, PUT, DELETE) are retried after connection errors or 429/5xx responses. Other requests are only retried when rejected with a `Retry-After` header. Retries wait at least as long as `Retry-After` asks, up to two minutes. Defaults to 0.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.Between(0, 10),
+				},
 			},
+			"max_concurrent_requests": schema.Int64Attribute{
+				MarkdownDescription: "Maximum number of API requests in flight at once across all resources. Unlimited by default.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"requests_per_second": schema.Float64Attribute{
+				MarkdownDescription: "Maximum rate at which API requests are started, shared by all resources. Unlimited by default. `Retry-After` headers on 429 and 503 responses are honoured regardless.",
+				Optional:            true,
+				Validators: []validator.Float64{
+					float64validator.AtLeast(0.1),
+				},
+			},
+			"retry_backoff": schema.StringAttribute{
+				MarkdownDescription: "Delay before the first retry as a Go duration, doubled for each further attempt. Defaults to `1s`.",
+				Optional:            true,
+			},
+			"proxy_url": schema.StringAttribute{
+				MarkdownDescription: "HTTP, HTTPS, or SOCKS5 proxy used to reach the NAS, e.g. `socks5://bastion:1080`. Defaults to the `HTTPS_PROXY` and `HTTP_PROXY` environment variables.",
+				Optional:            true,
+			},
+			"proxy_username": schema.StringAttribute{
+				MarkdownDescription: "Username for proxy authentication.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("proxy_url")),
+				},
+			},
+			"proxy_password": schema.StringAttribute{
+				MarkdownDescription: "Password for proxy authentication.",
+				Optional:            true,
+				Sensitive:           true,
+				Validators: []validator.String{
+					stringvalidator.AlsoRequires(path.MatchRoot("proxy_username")),
+				},
+			},
+			"ssh": schema.SingleNestedAttribute{
+				MarkdownDescription: "Reach the NAS through an SSH tunnel. All API traffic is forwarded over one SSH connection opened at configure time; `endpoint` is still used for the URL and TLS verification. Cannot be combined with `boxes`.",
+				Optional:            true,
+				Attributes: map[string]schema.Attribute{
+					"host": schema.StringAttribute{
+						MarkdownDescription: "SSH server to connect to.",
+						Required:            true,
+					},
+					"port": schema.Int64Attribute{
+						MarkdownDescription: "SSH server port. Defaults to 22.",
+						Optional:            true,
+						Validators: []validator.Int64{
+							int64validator.Between(1, 65535),
+						},
+					},
+					"user": schema.StringAttribute{
+						MarkdownDescription: "SSH user name.",
+						Required:            true,
+					},
+					"private_key": schema.StringAttribute{
+						MarkdownDescription: "PEM encoded private key used to authenticate.",
+						Optional:            true,
+						Sensitive:           true,
+						Validators: []validator.String{
+							stringvalidator.ExactlyOneOf(path.MatchRelative().AtParent().AtName("private_key_file")),
+						},
+					},
+					"private_key_file": schema.StringAttribute{
+						MarkdownDescription: "Path to the private key used to authenticate.",
+						Optional:            true,
+					},
+					"known_hosts_file": schema.StringAttribute{
+						MarkdownDescription: "known_hosts file used to verify the SSH server. Defaults to `~/.ssh/known_hosts`.",
+						Optional:            true,
+					},
+					"remote_host": schema.StringAttribute{
+						MarkdownDescription: "Host dialed from the SSH server. Defaults to the endpoint host.",
+						Optional:            true,
+					},
+					"remote_port": schema.Int64Attribute{
+						MarkdownDescription: "Port dialed from the SSH server. Defaults to the endpoint port.",
+						Optional:            true,
+						Validators: []validator.Int64{
+							int64validator.Between(1, 65535),
+						},
+					},
+				},
+			},
+			"min_api_version": schema.Int64Attribute{
+				MarkdownDescription: "Lowest NAS API version, as reported by `GET /v1/version`, the configuration is allowed to run against. Older firmware fails with an error naming the installed version.",
+				Optional:            true,
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"skip_user_validation": schema.BoolAttribute{
+				MarkdownDescription: "Do not contact the NAS while configuring the provider. API version negotiation and the `user` check run on the first API call instead, so `terraform plan -refresh=false` works offline. Requires `user` to be set.",
+				Optional:            true,
+			},
+			"read_only": schema.BoolAttribute{
+				MarkdownDescription: "Reject every create, update, and delete before it reaches the NAS, so plans and refresh-only applies can be run safely against production boxes.",
+				Optional:            true,
+			},
+			"default_labels": schema.MapAttribute{
+				MarkdownDescription: "Labels attached to every application and uploaded package managed by this provider, e.g. `managed-by` or `owner`. Labels set on a resource take precedence.",
+				ElementType:         types.StringType,
+				Optional:            true,
+			},
+		},
+		Blocks: map[string]schema.Block{
+			"default_publish": defaultPublishBlock(),
 		},
 	}
 }
@@ -67,56 +334,391 @@ func (p *LcmdProvider) Configure(ctx context.Context, req provider.ConfigureRequ
 		return
 	}
 
-	if data.Endpoint.IsUnknown() || data.Endpoint.IsNull() || data.User.IsUnknown() || data.User.IsNull() {
-		resp.Diagnostics.AddError("Missing configuration", "endpoint and user must be provided")
+	profileName := stringFromConfigOrEnv(data.Profile, "LCMD_PROFILE")
+	credentialsFile := stringFromConfigOrEnv(data.CredentialsFile, "LCMD_SHARED_CREDENTIALS_FILE")
+	fileSet := credentialsFile != ""
+	if !fileSet {
+		var err error
+		if credentialsFile, err = defaultCredentialsFile(); err != nil && profileName != "" {
+			resp.Diagnostics.AddError("Invalid profile", fmt.Sprintf("Unable to locate the shared credentials file: %s", err))
+			return
+		}
+	}
+	// An explicitly selected profile or file must exist; the default
+	// profile is optional.
+	required := profileName != "" || fileSet
+	if profileName == "" {
+		profileName = defaultProfile
+	}
+	var profile lcmdProfile
+	if credentialsFile != "" {
+		var err error
+		if profile, err = loadProfile(credentialsFile, profileName, required); err != nil {
+			resp.Diagnostics.AddError("Invalid profile", err.Error())
+			return
+		}
+	}
+
+	endpoint := stringFromConfigOrEnv(data.Endpoint, "LCMD_ENDPOINT")
+	if endpoint == "" {
+		endpoint = profile.Endpoint
+	}
+	uid := stringFromConfigOrEnv(data.User, "LCMD_USER")
+	if uid == "" {
+		uid = profile.User
+	}
+	if endpoint == "" {
+		resp.Diagnostics.AddError("Missing configuration", "endpoint must be provided, either in the provider block, via LCMD_ENDPOINT, or in the shared credentials file profile")
 		return
 	}
 
-	client, err := newAPIClient(data.Endpoint.ValueString())
+	var caPEM []byte
+	if !data.CAFile.IsNull() && data.CAFile.ValueString() != "" {
+		contents, err := os.ReadFile(data.CAFile.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid CA bundle", fmt.Sprintf("Unable to read ca_file: %s", err))
+			return
+		}
+		caPEM = contents
+	}
+	if !data.CAPEM.IsNull() && data.CAPEM.ValueString() != "" {
+		caPEM = []byte(data.CAPEM.ValueString())
+	}
+	insecure := data.Insecure.ValueBool()
+	if insecure {
+		resp.Diagnostics.AddWarning("TLS verification disabled", "insecure is enabled, so the NAS certificate is not verified. Traffic to the API can be intercepted.")
+	}
+	tlsConfig, err := newTLSConfig(caPEM, insecure)
 	if err != nil {
-		resp.Diagnostics.AddError("Failed to configure API client", err.Error())
+		resp.Diagnostics.AddError("Invalid CA bundle", err.Error())
 		return
 	}
 
-	users, err := client.ListUsers(ctx)
+	opts := apiClientOptions{
+		TLSConfig:  tlsConfig,
+		MaxRetries: int(data.MaxRetries.ValueInt64()),
+		DebugHTTP:  data.DebugHTTP.ValueBool(),
+
+		MaxConcurrentRequests: int(data.MaxConcurrent.ValueInt64()),
+		RequestsPerSecond:     data.RequestsPerSecond.ValueFloat64(),
+	}
+	for _, setting := range []struct {
+		name  string
+		value types.String
+		dest  *time.Duration
+	}{
+		{name: "request_timeout", value: data.RequestTimeout, dest: &opts.RequestTimeout},
+		{name: "upload_timeout", value: data.UploadTimeout, dest: &opts.UploadTimeout},
+		{name: "retry_backoff", value: data.RetryBackoff, dest: &opts.RetryBackoff},
+	} {
+		if setting.value.IsNull() || setting.value.IsUnknown() {
+			continue
+		}
+		d, err := time.ParseDuration(setting.value.ValueString())
+		if err != nil || d <= 0 {
+			resp.Diagnostics.AddError("Invalid duration", fmt.Sprintf("%s must be a positive duration such as 30s or 5m, got %q", setting.name, setting.value.ValueString()))
+			return
+		}
+		*setting.dest = d
+	}
+
+	if proxyURL := data.ProxyURL.ValueString(); proxyURL != "" {
+		parsed, err := parseProxyURL(proxyURL, data.ProxyUsername.ValueString(), data.ProxyPassword.ValueString())
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid proxy configuration", err.Error())
+			return
+		}
+		opts.ProxyURL = parsed
+	}
+
+	if data.SSH != nil {
+		if len(data.Boxes) > 0 {
+			resp.Diagnostics.AddError("Invalid ssh configuration", "ssh cannot be combined with boxes: the tunnel forwards every connection to one NAS, so the box endpoints would not be reached.")
+			return
+		}
+		tunnelConfig, err := sshTunnelConfigFromModel(data.SSH)
+		if err != nil {
+			resp.Diagnostics.AddError("Invalid ssh configuration", err.Error())
+			return
+		}
+		tunnel, err := openSSHTunnel(tunnelConfig)
+		if err != nil {
+			resp.Diagnostics.AddError("Unable to open SSH tunnel", err.Error())
+			return
+		}
+		opts.DialContext = tunnel.DialContext
+	}
+
+	client, err := newAPIClient(endpoint, opts)
 	if err != nil {
-		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to list UIDs, got error: %s", err))
+		resp.Diagnostics.AddError("Failed to configure API client", err.Error())
 		return
 	}
-	uid := data.User.ValueString()
-	if !containsUID(users, uid) {
-		resp.Diagnostics.AddError("Invalid user", fmt.Sprintf("User %s not found", uid))
+	client.token = stringFromConfigOrEnv(data.APIToken, "LCMD_API_TOKEN")
+	if data.CredentialsSource != nil {
+		if client.token, err = resolveCredential(ctx, data.CredentialsSource); err != nil {
+			resp.Diagnostics.AddError("Invalid credentials source", err.Error())
+			return
+		}
+	}
+	if helper := data.CredentialHelper.ValueString(); helper != "" {
+		if client.token, err = credentialHelperToken(ctx, helper, client.baseURL, uid); err != nil {
+			resp.Diagnostics.AddError("Credential helper failed", err.Error())
+			return
+		}
+	}
+	if client.token == "" {
+		client.token = profile.APIToken
+	}
+	client.ReadOnly = data.ReadOnly.ValueBool()
+	if client.publish, err = publishDefaultsFromModel(data.DefaultPublish); err != nil {
+		resp.Diagnostics.AddError("Invalid default_publish", err.Error())
 		return
 	}
-	client.User = uid
+	labels, diags := labelsMap(ctx, data.DefaultLabels)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client.labels = labels
+
+	minAPIVersion := int(data.MinAPIVersion.ValueInt64())
+	skip := data.SkipUserValidation.ValueBool()
+	if skip && uid == "" {
+		resp.Diagnostics.AddError("Missing configuration", "user must be set, in the provider block or via LCMD_USER, when skip_user_validation is enabled")
+		return
+	}
+	if !configureSession(ctx, client, uid, minAPIVersion, skip, "", &resp.Diagnostics) {
+		return
+	}
+
+	client.boxes = make(map[string]*LcmdClient, len(data.Boxes))
+	for _, name := range slices.Sorted(maps.Keys(data.Boxes)) {
+		box := data.Boxes[name]
+		boxUID := box.User.ValueString()
+		if skip && boxUID == "" {
+			resp.Diagnostics.AddError("Missing configuration", fmt.Sprintf("boxes[%q].user must be set when skip_user_validation is enabled", name))
+			return
+		}
+		scoped, err := newAPIClient(box.Endpoint.ValueString(), opts)
+		if err != nil {
+			resp.Diagnostics.AddError("Failed to configure API client", fmt.Sprintf("box %q: %s", name, err))
+			return
+		}
+		scoped.token = box.APIToken.ValueString()
+		scoped.ReadOnly = client.ReadOnly
+		scoped.publish = client.publish
+		scoped.labels = client.labels
+		scoped.teardown = client.teardown
+		scoped.boxes = client.boxes
+		if !configureSession(ctx, scoped, boxUID, minAPIVersion, skip, name, &resp.Diagnostics) {
+			return
+		}
+		client.boxes[name] = scoped
+	}
 
 	resp.DataSourceData = client
 	resp.ResourceData = client
+	resp.EphemeralResourceData = client
+}
+
+// configError is a connect failure with a diagnostic summary, so the eager
+// path can report it with a specific title.
+type configError struct {
+	summary string
+	detail  string
+}
+
+func (e *configError) Error() string {
+	return e.summary + ": " + e.detail
+}
+
+// connectClient performs the checks that need the NAS: it negotiates the API
+// version and enforces minAPIVersion, validates uid against the NAS users
+// (selecting the only user when uid is empty), and loads the maintenance
+// windows. It returns the UID to use.
+func connectClient(ctx context.Context, client *LcmdClient, uid string, minAPIVersion int) (string, error) {
+	if err := client.NegotiateVersion(ctx); err != nil {
+		return "", &configError{"Client Error", fmt.Sprintf("Unable to negotiate API version, got error: %s", err)}
+	}
+	tflog.Info(ctx, "connected to NAS", map[string]any{
+		"firmware_version":   client.session.server.Firmware,
+		"server_api_version": client.session.server.APIVersion,
+		"api_version":        client.session.apiVersion,
+	})
+	if client.session.server.APIVersion < minAPIVersion {
+		return "", &configError{"Unsupported NAS API version", fmt.Sprintf("The NAS runs %s, but min_api_version is %d. Upgrade the NAS firmware or lower min_api_version.", client.describeServer(), minAPIVersion)}
+	}
+
+	users, err := client.ListUsers(ctx)
+	if err != nil {
+		return "", &configError{"Client Error", fmt.Sprintf("Unable to list UIDs, got error: %s", err)}
+	}
+	if uid == "" {
+		if len(users) != 1 {
+			return "", &configError{"Missing configuration", fmt.Sprintf("The NAS has %d users, so user must be set in the provider block or via LCMD_USER. Available UIDs: %s", len(users), listUIDs(users))}
+		}
+		uid = users[0].UID
+		tflog.Info(ctx, "selected the only NAS user", map[string]any{"uid": uid})
+	}
+	if !containsUID(users, uid) {
+		return "", &configError{"Invalid user", fmt.Sprintf("User %s not found. Available UIDs: %s", uid, listUIDs(users))}
+	}
+
+	windows, err := client.ListMaintenanceWindows(ctx)
+	if err != nil && !errors.Is(err, errNotFound) {
+		return "", &configError{"Client Error", fmt.Sprintf("Unable to list maintenance windows, got error: %s", err)}
+	}
+	client.session.windows = windows
+	return uid, nil
+}
+
+// configureSession connects client and selects its user, or defers both to
+// the first API call when skip is set. box names the entry of `boxes` being
+// configured in diagnostics, and is empty for the provider endpoint.
+func configureSession(ctx context.Context, client *LcmdClient, uid string, minAPIVersion int, skip bool, box string, diags *diag.Diagnostics) bool {
+	if skip {
+		client.User = uid
+		client.session.connect = func(ctx context.Context) error {
+			_, err := connectClient(ctx, client, uid, minAPIVersion)
+			return err
+		}
+		return true
+	}
+	uid, err := connectClient(ctx, client, uid, minAPIVersion)
+	if err != nil {
+		summary, detail := "Client Error", err.Error()
+		var cfgErr *configError
+		if errors.As(err, &cfgErr) {
+			summary, detail = cfgErr.summary, cfgErr.detail
+		}
+		if box != "" {
+			detail = fmt.Sprintf("box %q: %s", box, detail)
+		}
+		diags.AddError(summary, detail)
+		return false
+	}
+	client.User = uid
+	return true
+}
+
+// stringFromConfigOrEnv returns the configured value, falling back to the
+// named environment variable when the attribute is null or unknown.
+func stringFromConfigOrEnv(val types.String, env string) string {
+	if val.IsNull() || val.IsUnknown() {
+		return os.Getenv(env)
+	}
+	return val.ValueString()
+}
+
+func sshTunnelConfigFromModel(m *SSHModel) (sshTunnelConfig, error) {
+	cfg := sshTunnelConfig{
+		Host:           m.Host.ValueString(),
+		Port:           int(m.Port.ValueInt64()),
+		User:           m.User.ValueString(),
+		KnownHostsFile: m.KnownHostsFile.ValueString(),
+		RemoteHost:     m.RemoteHost.ValueString(),
+		RemotePort:     int(m.RemotePort.ValueInt64()),
+	}
+	if key := m.PrivateKey.ValueString(); key != "" {
+		cfg.PrivateKey = []byte(key)
+		return cfg, nil
+	}
+	key, err := os.ReadFile(m.PrivateKeyFile.ValueString())
+	if err != nil {
+		return cfg, fmt.Errorf("read private_key_file: %w", err)
+	}
+	cfg.PrivateKey = key
+	return cfg, nil
+}
+
+// ensureWritable reports whether client may mutate the NAS, adding a
+// diagnostic when the provider is configured with read_only or when the NAS
+// has maintenance windows and none of them is open.
+func ensureWritable(ctx context.Context, client Client, diags *diag.Diagnostics) bool {
+	if !ensureNotReadOnly(client, diags) {
+		return false
+	}
+	if client == nil {
+		return true
+	}
+	windows, err := client.MaintenanceWindows(ctx)
+	if err != nil {
+		diags.AddError("Client Error", err.Error())
+		return false
+	}
+	if len(windows) == 0 {
+		return true
+	}
+	now := time.Now()
+	for _, window := range windows {
+		if open, err := windowOpen(window, now); err == nil && open {
+			return true
+		}
+	}
+	diags.AddError("Outside maintenance window", fmt.Sprintf("Changes to this NAS are only allowed during its maintenance windows: %s. Retry the apply while a window is open.", describeWindows(windows)))
+	return false
+}
+
+// ensureNotReadOnly reports whether client may mutate the NAS, adding a
+// diagnostic when the provider is configured with read_only. Maintenance
+// windows use it directly so they can be managed while no window is open.
+func ensureNotReadOnly(client Client, diags *diag.Diagnostics) bool {
+	if client == nil || !client.IsReadOnly() {
+		return true
+	}
+	diags.AddError("Provider is in read-only mode", "The lcmd provider is configured with read_only = true, so no changes are made to the NAS. Disable read_only to apply this change.")
+	return false
 }
 
 func containsUID(users []apiUser, uid string) bool {
 	return slices.ContainsFunc(users, func(u apiUser) bool { return u.UID == uid })
 }
 
+func listUIDs(users []apiUser) string {
+	uids := make([]string, len(users))
+	for i, u := range users {
+		uids[i] = u.UID
+	}
+	slices.Sort(uids)
+	if len(uids) == 0 {
+		return "(none)"
+	}
+	return strings.Join(uids, ", ")
+}
+
 func (p *LcmdProvider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		NewAppResource,
 		NewLPKBuildResource,
+		NewStaticSiteResource,
+		NewQuotaResource,
+		NewMaintenanceWindowResource,
+		NewGroupSharePermissionResource,
+		NewFileDownloadResource,
 	}
 }
 
 func (p *LcmdProvider) EphemeralResources(ctx context.Context) []func() ephemeral.EphemeralResource {
-	return nil
+	return []func() ephemeral.EphemeralResource{
+		NewAppSessionTokenEphemeralResource,
+	}
 }
 
 func (p *LcmdProvider) DataSources(ctx context.Context) []func() datasource.DataSource {
 	return []func() datasource.DataSource{
 		NewFileDataSource,
+		NewEventsDataSource,
+		NewAppStoreCategoriesDataSource,
+		NewFileStatDataSource,
+		NewAppResourceUsageDataSource,
 	}
 }
 
 func (p *LcmdProvider) Functions(ctx context.Context) []func() function.Function {
-	return nil
+	return []func() function.Function{
+		NewParseLPKFunction,
+	}
 }
 
 func New(version string) func() provider.Provider {
@@ -0,0 +1,93 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/provider/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// overwritePolicies are the values accepted by the registry `overwrite`
+// upload field.
+var overwritePolicies = []string{"always", "never", "if_changed"}
+
+// DefaultPublishModel holds the provider-wide publish settings inherited by
+// lcmd_lpk_build.
+type DefaultPublishModel struct {
+	RegistryURL types.String `tfsdk:"registry_url"`
+	Enabled     types.Bool   `tfsdk:"enabled"`
+	NamePrefix  types.String `tfsdk:"name_prefix"`
+	Overwrite   types.String `tfsdk:"overwrite"`
+}
+
+// publishDefaults is the resolved form of DefaultPublishModel. An unset
+// Enabled keeps publishing on.
+type publishDefaults struct {
+	RegistryURL string
+	Enabled     *bool
+	NamePrefix  string
+	Overwrite   string
+}
+
+func defaultPublishBlock() schema.SingleNestedBlock {
+	return schema.SingleNestedBlock{
+		MarkdownDescription: "Publish settings inherited by every `lcmd_lpk_build` unless its `publish` block sets them.",
+		Attributes: map[string]schema.Attribute{
+			"registry_url": schema.StringAttribute{
+				MarkdownDescription: "Base URL of the registry API packages are uploaded to. Defaults to `endpoint`.",
+				Optional:            true,
+			},
+			"enabled": schema.BoolAttribute{
+				MarkdownDescription: "Whether builds are uploaded. Defaults to true.",
+				Optional:            true,
+			},
+			"name_prefix": schema.StringAttribute{
+				MarkdownDescription: "Prefix added to the upload name of builds that do not set `publish.name`, e.g. `team-`.",
+				Optional:            true,
+			},
+			"overwrite": schema.StringAttribute{
+				MarkdownDescription: "What the registry does when an upload with the same name and version exists: `always` replaces it, `never` rejects the upload, and `if_changed` replaces it only when the content differs. Defaults to the registry behavior.",
+				Optional:            true,
+				Validators: []validator.String{
+					stringvalidator.OneOf(overwritePolicies...),
+				},
+			},
+		},
+	}
+}
+
+func publishDefaultsFromModel(m *DefaultPublishModel) (publishDefaults, error) {
+	var defaults publishDefaults
+	if m == nil {
+		return defaults, nil
+	}
+	if registryURL := m.RegistryURL.ValueString(); registryURL != "" {
+		if err := validateRegistryURL(registryURL); err != nil {
+			return defaults, err
+		}
+		defaults.RegistryURL = registryURL
+	}
+	if !m.Enabled.IsNull() {
+		enabled := m.Enabled.ValueBool()
+		defaults.Enabled = &enabled
+	}
+	defaults.NamePrefix = m.NamePrefix.ValueString()
+	defaults.Overwrite = m.Overwrite.ValueString()
+	return defaults, nil
+}
+
+func validateRegistryURL(registryURL string) error {
+	parsed, err := url.Parse(registryURL)
+	if err != nil {
+		return fmt.Errorf("invalid registry_url: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return fmt.Errorf("registry_url must be an http or https URL, got %q", registryURL)
+	}
+	return nil
+}
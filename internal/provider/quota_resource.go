@@ -0,0 +1,229 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/int64validator"
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+var _ resource.Resource = &QuotaResource{}
+var _ resource.ResourceWithImportState = &QuotaResource{}
+
+type QuotaResource struct {
+	client Client
+}
+
+type QuotaModel struct {
+	ID          types.String `tfsdk:"id"`
+	Box         types.String `tfsdk:"box"`
+	UID         types.String `tfsdk:"uid"`
+	AppID       types.String `tfsdk:"appid"`
+	LimitBytes  types.Int64  `tfsdk:"limit_bytes"`
+	Enforcement types.String `tfsdk:"enforcement"`
+	UsageBytes  types.Int64  `tfsdk:"usage_bytes"`
+}
+
+func NewQuotaResource() resource.Resource {
+	return &QuotaResource{}
+}
+
+func (r *QuotaResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_quota"
+}
+
+func (r *QuotaResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	resp.Schema = schema.Schema{
+		Description: "Manages a storage quota for a NAS user or application.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Quota identifier assigned by the NAS.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"box": boxAttribute(),
+			"uid": schema.StringAttribute{
+				Optional:    true,
+				Description: "User the quota applies to. Exactly one of uid or appid must be set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+				Validators: []validator.String{
+					stringvalidator.ExactlyOneOf(path.MatchRoot("uid"), path.MatchRoot("appid")),
+				},
+			},
+			"appid": schema.StringAttribute{
+				Optional:    true,
+				Description: "Application the quota applies to. Exactly one of uid or appid must be set.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"limit_bytes": schema.Int64Attribute{
+				Required:    true,
+				Description: "Maximum storage in bytes.",
+				Validators: []validator.Int64{
+					int64validator.AtLeast(1),
+				},
+			},
+			"enforcement": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString("hard"),
+				Description: "Enforcement mode: hard rejects writes over the limit, soft only reports them. Defaults to hard.",
+				Validators: []validator.String{
+					stringvalidator.OneOf("hard", "soft"),
+				},
+			},
+			"usage_bytes": schema.Int64Attribute{
+				Computed:    true,
+				Description: "Storage currently used in bytes.",
+			},
+		},
+	}
+}
+
+func (r *QuotaResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	client, ok := req.ProviderData.(Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected Client, got %T", req.ProviderData))
+		return
+	}
+	r.client = client
+}
+
+func (r *QuotaResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var plan QuotaModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, plan.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	quota, err := client.SetQuota(ctx, quotaRequest(&plan))
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to set quota, got error: %s", err))
+		return
+	}
+	applyQuota(&plan, quota)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *QuotaResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var state QuotaModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, state.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	quota, err := client.GetQuota(ctx, state.ID.ValueString())
+	if errors.Is(err, errNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("Read quota failed", err.Error())
+		return
+	}
+	applyQuota(&state, quota)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *QuotaResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var plan, state QuotaModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, plan.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	plan.ID = state.ID
+	quota, err := client.SetQuota(ctx, quotaRequest(&plan))
+	if err != nil {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to update quota, got error: %s", err))
+		return
+	}
+	applyQuota(&plan, quota)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *QuotaResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var state QuotaModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, state.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	if err := client.DeleteQuota(ctx, state.ID.ValueString()); err != nil && !errors.Is(err, errNotFound) {
+		resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to delete quota, got error: %s", err))
+	}
+}
+
+func (r *QuotaResource) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
+	importStateWithBox(ctx, req, resp)
+}
+
+func quotaRequest(data *QuotaModel) apiQuota {
+	return apiQuota{
+		ID:          data.ID.ValueString(),
+		UID:         data.UID.ValueString(),
+		AppID:       data.AppID.ValueString(),
+		LimitBytes:  data.LimitBytes.ValueInt64(),
+		Enforcement: data.Enforcement.ValueString(),
+	}
+}
+
+func applyQuota(data *QuotaModel, quota *apiQuota) {
+	data.ID = types.StringValue(quota.ID)
+	data.UID = stringOrNull(quota.UID)
+	data.AppID = stringOrNull(quota.AppID)
+	data.LimitBytes = types.Int64Value(quota.LimitBytes)
+	if quota.Enforcement != "" {
+		data.Enforcement = types.StringValue(quota.Enforcement)
+	}
+	data.UsageBytes = types.Int64Value(quota.UsageBytes)
+}
@@ -0,0 +1,101 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// maxRetryAfter caps how long a Retry-After header can stall a request.
+const maxRetryAfter = 2 * time.Minute
+
+// requestLimiter bounds the load the provider puts on the NAS: requests
+// start at most perSecond times per second, and at most cap(slots) are in
+// flight at once. A nil limiter or slots channel disables that bound.
+type requestLimiter struct {
+	limiter *rate.Limiter
+	slots   chan struct{}
+}
+
+func newRequestLimiter(maxConcurrent int, perSecond float64) *requestLimiter {
+	l := &requestLimiter{}
+	if perSecond > 0 {
+		burst := max(1, int(perSecond))
+		l.limiter = rate.NewLimiter(rate.Limit(perSecond), burst)
+	}
+	if maxConcurrent > 0 {
+		l.slots = make(chan struct{}, maxConcurrent)
+	}
+	return l
+}
+
+// acquire waits for permission to send a request. The returned function
+// releases the concurrency slot and must be called once the response has
+// been read.
+func (l *requestLimiter) acquire(ctx context.Context) (func(), error) {
+	if l.slots != nil {
+		select {
+		case l.slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	release := func() {
+		if l.slots != nil {
+			<-l.slots
+		}
+	}
+	if l.limiter != nil {
+		if err := l.limiter.Wait(ctx); err != nil {
+			release()
+			return nil, err
+		}
+	}
+	return release, nil
+}
+
+// retryAfterError carries the delay a 429 or 503 response asked for.
+type retryAfterError struct {
+	err   error
+	after time.Duration
+}
+
+func (e *retryAfterError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryAfterError) Unwrap() error {
+	return e.err
+}
+
+// retryAfter returns the delay requested by err, if any.
+func retryAfter(err error) (time.Duration, bool) {
+	var ra *retryAfterError
+	if errors.As(err, &ra) {
+		return ra.after, true
+	}
+	return 0, false
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an HTTP
+// date, capped at maxRetryAfter.
+func parseRetryAfter(header string, now time.Time) (time.Duration, bool) {
+	if header == "" {
+		return 0, false
+	}
+	var d time.Duration
+	if seconds, err := strconv.Atoi(header); err == nil {
+		d = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(header); err == nil {
+		d = at.Sub(now)
+	} else {
+		return 0, false
+	}
+	return min(max(d, 0), maxRetryAfter), true
+}
@@ -0,0 +1,84 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+)
+
+// relativePathValidator rejects paths that are absolute or climb out of the
+// directory they are resolved against with "..", so a subpath taken from a
+// less trusted module input cannot point the build at arbitrary files.
+type relativePathValidator struct{}
+
+func (v relativePathValidator) Description(_ context.Context) string {
+	return "value must be a relative path that stays within its base directory"
+}
+
+func (v relativePathValidator) MarkdownDescription(ctx context.Context) string {
+	return v.Description(ctx)
+}
+
+func (v relativePathValidator) ValidateString(ctx context.Context, req validator.StringRequest, resp *validator.StringResponse) {
+	if req.ConfigValue.IsNull() || req.ConfigValue.IsUnknown() {
+		return
+	}
+	if err := checkRelativePath(req.ConfigValue.ValueString()); err != nil {
+		resp.Diagnostics.AddAttributeError(req.Path, "Invalid path", err.Error())
+	}
+}
+
+// checkRelativePath returns an error unless rel is a relative path that does
+// not escape its base directory. Both slash and OS separators are accepted,
+// and an empty path stands for the base directory itself.
+func checkRelativePath(rel string) error {
+	if rel == "" {
+		return nil
+	}
+	if filepath.IsAbs(rel) || strings.HasPrefix(rel, "/") || strings.HasPrefix(rel, `\`) {
+		return fmt.Errorf("path %q must be relative", rel)
+	}
+	if !filepath.IsLocal(filepath.FromSlash(rel)) {
+		return fmt.Errorf("path %q escapes its base directory", rel)
+	}
+	return nil
+}
+
+// isWithin reports whether target is base or lies beneath it.
+func isWithin(base, target string) bool {
+	rel, err := filepath.Rel(base, target)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// joinWithin joins rel onto base and resolves symlinks, failing when the
+// result lies outside base. It guards subpaths inside cloned or extracted
+// sources, whose symlinks are not under the user's control.
+func joinWithin(base, rel string) (string, error) {
+	if err := checkRelativePath(rel); err != nil {
+		return "", err
+	}
+	return resolveWithin(base, filepath.Join(base, filepath.FromSlash(rel)))
+}
+
+// resolveWithin resolves the symlinks in target and returns it when the
+// result still lies beneath base.
+func resolveWithin(base, target string) (string, error) {
+	realBase, err := filepath.EvalSymlinks(base)
+	if err != nil {
+		return "", err
+	}
+	real, err := filepath.EvalSymlinks(target)
+	if err != nil {
+		return "", err
+	}
+	if !isWithin(realBase, real) {
+		rel, _ := filepath.Rel(base, target)
+		return "", fmt.Errorf("path %q resolves outside %s", filepath.ToSlash(rel), base)
+	}
+	return target, nil
+}
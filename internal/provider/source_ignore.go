@@ -0,0 +1,69 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// sourceIgnore holds the source.ignore patterns of an lpk_build, in the
+// gitignore subset documented on the attribute.
+type sourceIgnore []ignorePattern
+
+type ignorePattern struct {
+	glob     string
+	anchored bool
+	dirOnly  bool
+}
+
+// parseSourceIgnore compiles the ignore patterns of source.
+func parseSourceIgnore(ctx context.Context, source *LPKBuildSourceModel) (sourceIgnore, error) {
+	if source == nil || source.Ignore.IsNull() || source.Ignore.IsUnknown() {
+		return nil, nil
+	}
+	var raw []string
+	if diags := source.Ignore.ElementsAs(ctx, &raw, false); diags.HasError() {
+		return nil, errors.New("source.ignore must be a list of strings")
+	}
+	var ignore sourceIgnore
+	for _, pattern := range raw {
+		p := ignorePattern{}
+		pattern = strings.TrimSpace(pattern)
+		if strings.HasSuffix(pattern, "/") {
+			p.dirOnly = true
+			pattern = strings.TrimSuffix(pattern, "/")
+		}
+		p.anchored = strings.Contains(pattern, "/")
+		p.glob = strings.TrimPrefix(pattern, "/")
+		if p.glob == "" {
+			continue
+		}
+		if _, err := path.Match(p.glob, ""); err != nil {
+			return nil, fmt.Errorf("invalid source.ignore pattern %q: %w", pattern, err)
+		}
+		ignore = append(ignore, p)
+	}
+	return ignore, nil
+}
+
+// matches reports whether the slash-separated path rel, relative to the
+// source root, is ignored.
+func (s sourceIgnore) matches(rel string, dir bool) bool {
+	for _, p := range s {
+		if p.dirOnly && !dir {
+			continue
+		}
+		name := path.Base(rel)
+		if p.anchored {
+			name = rel
+		}
+		if ok, _ := path.Match(p.glob, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// stageWorkspace copies the source tree at src into a new temporary
+// directory, so templates can be rendered and the manifest edited without
+// touching the user's checkout. Terraform data and built artifacts are left
+// out, and symlinks are copied as links. The caller removes the returned
+// directory, which is set even when an error is returned after creating it.
+func stageWorkspace(src string) (string, error) {
+	dst, err := os.MkdirTemp("", "lpk-workspace-*")
+	if err != nil {
+		return "", err
+	}
+	err = filepath.WalkDir(src, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		name := entry.Name()
+		switch {
+		case entry.IsDir():
+			if rel == ".terraform" {
+				return filepath.SkipDir
+			}
+			if rel == "." {
+				return nil
+			}
+			return os.Mkdir(target, 0o755)
+		case entry.Type()&fs.ModeSymlink != 0:
+			link, err := os.Readlink(path)
+			if err != nil {
+				return err
+			}
+			return os.Symlink(link, target)
+		case !entry.Type().IsRegular():
+			return nil
+		case strings.HasSuffix(name, ".lpk") || strings.HasSuffix(name, checksumManifestSuffix):
+			return nil
+		}
+		return copyFile(path, target)
+	})
+	return dst, err
+}
+
+func copyFile(src, dst string) error {
+	info, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
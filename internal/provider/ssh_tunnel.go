@@ -0,0 +1,117 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// sshTunnelConfig describes the bastion used to reach the NAS API.
+type sshTunnelConfig struct {
+	Host           string
+	Port           int
+	User           string
+	PrivateKey     []byte
+	KnownHostsFile string
+	// RemoteHost and RemotePort, when set, replace the endpoint host and port
+	// dialed from the SSH server, e.g. 127.0.0.1:443 on the NAS itself.
+	RemoteHost string
+	RemotePort int
+}
+
+// sshTunnel forwards API connections over a single SSH client connection.
+type sshTunnel struct {
+	client *ssh.Client
+	config sshTunnelConfig
+}
+
+var (
+	openTunnelsMu sync.Mutex
+	openTunnels   []*sshTunnel
+)
+
+func openSSHTunnel(cfg sshTunnelConfig) (*sshTunnel, error) {
+	signer, err := ssh.ParsePrivateKey(cfg.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("parse ssh private key: %w", err)
+	}
+	knownHostsFile := cfg.KnownHostsFile
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("locate known_hosts: %w", err)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("load known_hosts: %w", err)
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = 22
+	}
+	client, err := ssh.Dial("tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(port)), &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         defaultRequestTimeout,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("ssh dial %s: %w", cfg.Host, err)
+	}
+	tunnel := &sshTunnel{client: client, config: cfg}
+	openTunnelsMu.Lock()
+	openTunnels = append(openTunnels, tunnel)
+	openTunnelsMu.Unlock()
+	return tunnel, nil
+}
+
+// DialContext opens a connection to addr from the SSH server, substituting the
+// configured remote host and port.
+func (t *sshTunnel) DialContext(ctx context.Context, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	if t.config.RemoteHost != "" {
+		host = t.config.RemoteHost
+	}
+	if t.config.RemotePort != 0 {
+		port = strconv.Itoa(t.config.RemotePort)
+	}
+	conn, err := t.client.DialContext(ctx, network, net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, fmt.Errorf("ssh tunnel: %w", err)
+	}
+	return conn, nil
+}
+
+func (t *sshTunnel) Close() error {
+	return t.client.Close()
+}
+
+// CloseTunnels tears down every SSH tunnel opened by Configure. It is called
+// when the provider server stops.
+func CloseTunnels() error {
+	openTunnelsMu.Lock()
+	defer openTunnelsMu.Unlock()
+	var errs []error
+	for _, tunnel := range openTunnels {
+		if err := tunnel.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	openTunnels = nil
+	return errors.Join(errs...)
+}
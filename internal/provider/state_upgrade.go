@@ -0,0 +1,111 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/defaults"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+)
+
+// Schema versions of lcmd_app and lcmd_lpk_build. A schema change that would
+// break decoding existing state or make it differ from a fresh apply, such
+// as removing, renaming, or retyping an attribute or adding one with a
+// default, bumps the version and adds an upgrader from the previous version
+// to the resource's UpgradeState. Renamed and removed attributes stay in the
+// schema with a DeprecationMessage for at least one minor release first, so
+// configurations can move over before state stops carrying them.
+const (
+	appSchemaVersion      = 1
+	lpkBuildSchemaVersion = 1
+)
+
+// rawStateUpgrader returns an upgrader that decodes prior state as JSON,
+// drops attributes current no longer has, and fills in the static defaults
+// of attributes that are missing or null, so state written before they
+// existed does not show a diff against the defaults.
+func rawStateUpgrader(current schema.Schema) resource.StateUpgrader {
+	return resource.StateUpgrader{
+		StateUpgrader: func(ctx context.Context, req resource.UpgradeStateRequest, resp *resource.UpgradeStateResponse) {
+			if req.RawState == nil || req.RawState.JSON == nil {
+				resp.Diagnostics.AddError("Unable to upgrade state", "The prior state is not stored as JSON.")
+				return
+			}
+			var attrs map[string]json.RawMessage
+			if err := json.Unmarshal(req.RawState.JSON, &attrs); err != nil {
+				resp.Diagnostics.AddError("Unable to upgrade state", err.Error())
+				return
+			}
+			for name := range attrs {
+				_, isAttr := current.Attributes[name]
+				_, isBlock := current.Blocks[name]
+				if !isAttr && !isBlock {
+					delete(attrs, name)
+				}
+			}
+			for name, attribute := range current.Attributes {
+				if raw, ok := attrs[name]; ok && string(raw) != "null" {
+					continue
+				}
+				value, ok := staticDefault(ctx, attribute)
+				if !ok {
+					continue
+				}
+				encoded, err := json.Marshal(value)
+				if err != nil {
+					resp.Diagnostics.AddError("Unable to upgrade state", fmt.Sprintf("encode default of %s: %s", name, err))
+					return
+				}
+				attrs[name] = encoded
+			}
+			upgraded, err := json.Marshal(attrs)
+			if err != nil {
+				resp.Diagnostics.AddError("Unable to upgrade state", err.Error())
+				return
+			}
+			resp.DynamicValue = &tfprotov6.DynamicValue{JSON: upgraded}
+		},
+	}
+}
+
+// staticDefault returns the default of a primitive attribute, if it has one.
+func staticDefault(ctx context.Context, attribute schema.Attribute) (any, bool) {
+	switch a := attribute.(type) {
+	case schema.StringAttribute:
+		if a.Default == nil {
+			return nil, false
+		}
+		var out defaults.StringResponse
+		a.Default.DefaultString(ctx, defaults.StringRequest{}, &out)
+		if out.Diagnostics.HasError() || out.PlanValue.IsNull() || out.PlanValue.IsUnknown() {
+			return nil, false
+		}
+		return out.PlanValue.ValueString(), true
+	case schema.BoolAttribute:
+		if a.Default == nil {
+			return nil, false
+		}
+		var out defaults.BoolResponse
+		a.Default.DefaultBool(ctx, defaults.BoolRequest{}, &out)
+		if out.Diagnostics.HasError() || out.PlanValue.IsNull() || out.PlanValue.IsUnknown() {
+			return nil, false
+		}
+		return out.PlanValue.ValueBool(), true
+	case schema.Int64Attribute:
+		if a.Default == nil {
+			return nil, false
+		}
+		var out defaults.Int64Response
+		a.Default.DefaultInt64(ctx, defaults.Int64Request{}, &out)
+		if out.Diagnostics.HasError() || out.PlanValue.IsNull() || out.PlanValue.IsUnknown() {
+			return nil, false
+		}
+		return out.PlanValue.ValueInt64(), true
+	}
+	return nil, false
+}
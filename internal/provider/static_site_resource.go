@@ -0,0 +1,502 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/planmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringdefault"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema/stringplanmodifier"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"gopkg.in/yaml.v3"
+)
+
+var _ resource.Resource = &StaticSiteResource{}
+var _ resource.ResourceWithModifyPlan = &StaticSiteResource{}
+
+type StaticSiteResource struct {
+	client Client
+}
+
+type StaticSiteModel struct {
+	ID              types.String `tfsdk:"id"`
+	Box             types.String `tfsdk:"box"`
+	SourceDir       types.String `tfsdk:"source_dir"`
+	AppID           types.String `tfsdk:"appid"`
+	Name            types.String `tfsdk:"name"`
+	Version         types.String `tfsdk:"version"`
+	Subdomain       types.String `tfsdk:"subdomain"`
+	ContentHash     types.String `tfsdk:"content_hash"`
+	SHA256          types.String `tfsdk:"sha256"`
+	LPKURL          types.String `tfsdk:"lpk_url"`
+	UploadID        types.String `tfsdk:"upload_id"`
+	Domain          types.String `tfsdk:"domain"`
+	Labels          types.Map    `tfsdk:"labels"`
+	EffectiveLabels types.Map    `tfsdk:"effective_labels"`
+}
+
+type staticSiteManifest struct {
+	AppID       string                `yaml:"appid"`
+	Name        string                `yaml:"name"`
+	Version     string                `yaml:"version"`
+	Application staticSiteApplication `yaml:"application"`
+}
+
+type staticSiteApplication struct {
+	Subdomain string   `yaml:"subdomain"`
+	Routes    []string `yaml:"routes"`
+}
+
+const defaultStaticSiteVersion = "0.0.1"
+
+// staticSiteEpoch is stamped on every archive entry so identical content
+// always produces a byte-identical package.
+var staticSiteEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+func NewStaticSiteResource() resource.Resource {
+	return &StaticSiteResource{}
+}
+
+func (r *StaticSiteResource) Metadata(_ context.Context, req resource.MetadataRequest, resp *resource.MetadataResponse) {
+	resp.TypeName = req.ProviderTypeName + "_static_site"
+}
+
+func (r *StaticSiteResource) Schema(_ context.Context, _ resource.SchemaRequest, resp *resource.SchemaResponse) {
+	labels, effectiveLabels := labelsAttributes("site", func() Client { return r.client })
+	resp.Schema = schema.Schema{
+		Description: "Packages a local directory of static files into a minimal LPK, publishes it to the NAS registry, and installs it under a subdomain.",
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Computed:    true,
+				Description: "Application ID of the installed site.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.UseStateForUnknown(),
+				},
+			},
+			"box": boxAttribute(),
+			"source_dir": schema.StringAttribute{
+				Required:    true,
+				Description: "Local directory containing the static files to serve.",
+			},
+			"appid": schema.StringAttribute{
+				Required:    true,
+				Description: "Application ID written to the generated manifest.",
+				PlanModifiers: []planmodifier.String{
+					stringplanmodifier.RequiresReplace(),
+				},
+			},
+			"name": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Description: "Display name written to the generated manifest. Defaults to the appid.",
+			},
+			"version": schema.StringAttribute{
+				Optional:    true,
+				Computed:    true,
+				Default:     stringdefault.StaticString(defaultStaticSiteVersion),
+				Description: "Version written to the generated manifest. Defaults to 0.0.1.",
+			},
+			"subdomain": schema.StringAttribute{
+				Required:    true,
+				Description: "Subdomain the site is served under.",
+			},
+			"content_hash": schema.StringAttribute{
+				Computed:    true,
+				Description: "Hash of the files in source_dir used to detect content changes.",
+			},
+			"sha256": schema.StringAttribute{
+				Computed:    true,
+				Description: "SHA256 of the generated LPK.",
+			},
+			"lpk_url": schema.StringAttribute{
+				Computed:    true,
+				Description: "Download URL returned by NAS registry.",
+			},
+			"upload_id":        schema.StringAttribute{Computed: true},
+			"domain":           schema.StringAttribute{Computed: true, Description: "Domain the site is reachable at."},
+			"labels":           labels,
+			"effective_labels": effectiveLabels,
+		},
+	}
+}
+
+func (r *StaticSiteResource) Configure(_ context.Context, req resource.ConfigureRequest, resp *resource.ConfigureResponse) {
+	if req.ProviderData == nil {
+		return
+	}
+	client, ok := req.ProviderData.(Client)
+	if !ok {
+		resp.Diagnostics.AddError("Unexpected Resource Configure Type", fmt.Sprintf("Expected Client, got %T", req.ProviderData))
+		return
+	}
+	r.client = client
+}
+
+func (r *StaticSiteResource) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
+	if req.Plan.Raw.IsNull() || req.State.Raw.IsNull() {
+		return
+	}
+	var plan, state StaticSiteModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if plan.SourceDir.IsUnknown() {
+		return
+	}
+	if plan.Name.IsUnknown() && !plan.AppID.IsUnknown() {
+		plan.Name = plan.AppID
+	}
+	fingerprint, err := hashStaticSite(plan.SourceDir.ValueString())
+	if err != nil {
+		resp.Diagnostics.AddError("Hash error", err.Error())
+		return
+	}
+	if fingerprint == state.ContentHash.ValueString() &&
+		plan.Name.Equal(state.Name) &&
+		plan.Version.Equal(state.Version) &&
+		plan.Subdomain.Equal(state.Subdomain) &&
+		plan.EffectiveLabels.Equal(state.EffectiveLabels) {
+		plan.ContentHash = state.ContentHash
+		plan.SHA256 = state.SHA256
+		plan.LPKURL = state.LPKURL
+		plan.UploadID = state.UploadID
+		plan.Domain = state.Domain
+	} else {
+		plan.ContentHash = types.StringValue(fingerprint)
+		plan.SHA256 = types.StringUnknown()
+		plan.LPKURL = types.StringUnknown()
+		plan.UploadID = types.StringUnknown()
+		plan.Domain = types.StringUnknown()
+	}
+	resp.Diagnostics.Append(resp.Plan.Set(ctx, &plan)...)
+}
+
+func (r *StaticSiteResource) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var plan StaticSiteModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, plan.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	if err := r.publish(ctx, client, &plan); err != nil {
+		resp.Diagnostics.AddError("Publish error", err.Error())
+		return
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *StaticSiteResource) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var state StaticSiteModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if state.ID.IsNull() || state.ID.ValueString() == "" {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	client := boxClient(r.client, state.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	app, err := client.GetApp(ctx, state.ID.ValueString())
+	if errors.Is(err, errNotFound) {
+		resp.State.RemoveResource(ctx)
+		return
+	}
+	if err != nil {
+		resp.Diagnostics.AddError("QueryApplication failed", err.Error())
+		return
+	}
+	state.Domain = stringOrNull(app.Domain)
+	resp.Diagnostics.Append(resp.State.Set(ctx, &state)...)
+}
+
+func (r *StaticSiteResource) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	var plan, state StaticSiteModel
+	resp.Diagnostics.Append(req.Plan.Get(ctx, &plan)...)
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	client := boxClient(r.client, plan.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	if !plan.SHA256.IsUnknown() {
+		resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+		return
+	}
+	if err := r.publish(ctx, client, &plan); err != nil {
+		resp.Diagnostics.AddError("Publish error", err.Error())
+		return
+	}
+	if !state.UploadID.IsNull() && state.UploadID.ValueString() != "" && state.UploadID.ValueString() != plan.UploadID.ValueString() {
+		if err := client.DeleteLPK(ctx, state.UploadID.ValueString()); err != nil && !errors.Is(err, errNotFound) {
+			resp.Diagnostics.AddWarning("Delete superseded upload failed", err.Error())
+		}
+	}
+	resp.Diagnostics.Append(resp.State.Set(ctx, &plan)...)
+}
+
+func (r *StaticSiteResource) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	var state StaticSiteModel
+	resp.Diagnostics.Append(req.State.Get(ctx, &state)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	if r.client == nil {
+		resp.Diagnostics.AddError("Provider not configured", "")
+		return
+	}
+	client := boxClient(r.client, state.Box, &resp.Diagnostics)
+	if resp.Diagnostics.HasError() || !ensureWritable(ctx, client, &resp.Diagnostics) {
+		return
+	}
+	if !state.ID.IsNull() && state.ID.ValueString() != "" {
+		if err := client.DeleteApp(ctx, state.ID.ValueString(), false); err != nil && !errors.Is(err, errNotFound) {
+			resp.Diagnostics.AddError("Client Error", fmt.Sprintf("Unable to uninstall static site, got error: %s", err))
+			return
+		}
+	}
+	if !state.UploadID.IsNull() && state.UploadID.ValueString() != "" {
+		if err := client.DeleteLPK(ctx, state.UploadID.ValueString()); err != nil && !errors.Is(err, errNotFound) {
+			resp.Diagnostics.AddError("Delete upload failed", err.Error())
+			return
+		}
+	}
+}
+
+// publish packages the site, uploads the package, and installs it through
+// client, filling the computed attributes of data.
+func (r *StaticSiteResource) publish(ctx context.Context, client Client, data *StaticSiteModel) error {
+	sourceDir := data.SourceDir.ValueString()
+	if sourceDir == "" {
+		return errors.New("source_dir must be set")
+	}
+	fingerprint, err := hashStaticSite(sourceDir)
+	if err != nil {
+		return fmt.Errorf("hash source: %w", err)
+	}
+	if data.Name.IsNull() || data.Name.IsUnknown() || data.Name.ValueString() == "" {
+		data.Name = data.AppID
+	}
+	manifest := staticSiteManifest{
+		AppID:   data.AppID.ValueString(),
+		Name:    data.Name.ValueString(),
+		Version: data.Version.ValueString(),
+		Application: staticSiteApplication{
+			Subdomain: data.Subdomain.ValueString(),
+			Routes:    []string{"/=file:///lzcapp/pkg/content/"},
+		},
+	}
+	tmp, err := os.MkdirTemp("", "lpk-static-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+	lpkPath := filepath.Join(tmp, fmt.Sprintf("%s-%s.lpk", manifest.AppID, manifest.Version))
+	if err := packageStaticSite(sourceDir, manifest, lpkPath); err != nil {
+		return fmt.Errorf("package site: %w", err)
+	}
+	sha, err := computeSHA(lpkPath)
+	if err != nil {
+		return err
+	}
+	labels, diags := labelsMap(ctx, data.EffectiveLabels)
+	if diags.HasError() {
+		return errors.New("convert effective_labels")
+	}
+	upload, err := client.UploadLPK(ctx, apiUploadRequest{
+		UID:     client.UID(),
+		Name:    manifest.Name,
+		Version: manifest.Version,
+		Labels:  labels,
+	}, lpkPath)
+	if err != nil {
+		return fmt.Errorf("upload error: %w", err)
+	}
+	app, err := client.InstallApp(ctx, apiInstallRequest{LPKURL: upload.DownloadURL, Wait: true, Labels: labels})
+	if err != nil {
+		return fmt.Errorf("install error: %w", err)
+	}
+	data.ID = types.StringValue(manifest.AppID)
+	if app.AppID != "" {
+		data.ID = types.StringValue(app.AppID)
+	}
+	data.ContentHash = types.StringValue(fingerprint)
+	data.SHA256 = types.StringValue(sha)
+	if upload.SHA256 != "" {
+		data.SHA256 = types.StringValue(upload.SHA256)
+	}
+	data.LPKURL = types.StringValue(upload.DownloadURL)
+	data.UploadID = types.StringValue(upload.ID)
+	data.Domain = stringOrNull(app.Domain)
+	return nil
+}
+
+// staticSiteFiles returns the regular files beneath root as sorted slash
+// separated relative paths.
+func staticSiteFiles(root string) ([]string, error) {
+	var files []string
+	err := filepath.WalkDir(root, func(path string, entry fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if entry.IsDir() {
+			if entry.Name() == ".git" && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func hashStaticSite(root string) (string, error) {
+	files, err := staticSiteFiles(root)
+	if err != nil {
+		return "", err
+	}
+	if len(files) == 0 {
+		return "", fmt.Errorf("source_dir %s contains no files", root)
+	}
+	var buf bytes.Buffer
+	for _, rel := range files {
+		sum, err := computeSHA(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&buf, "%s\x00%s\n", rel, sum)
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// packageStaticSite writes an LPK archive containing the generated manifest
+// and a content.tar.gz of sourceDir to dest.
+func packageStaticSite(sourceDir string, manifest staticSiteManifest, dest string) error {
+	manifestData, err := yaml.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	content, err := tarStaticSite(sourceDir)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	archive := zip.NewWriter(out)
+	for _, entry := range []struct {
+		name string
+		data []byte
+	}{
+		{name: "manifest.yml", data: manifestData},
+		{name: "content.tar.gz", data: content},
+	} {
+		w, err := archive.CreateHeader(&zip.FileHeader{Name: entry.name, Method: zip.Deflate, Modified: staticSiteEpoch})
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(entry.data); err != nil {
+			return err
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+func tarStaticSite(root string) ([]byte, error) {
+	files, err := staticSiteFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, rel := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		header := &tar.Header{
+			Name:    rel,
+			Mode:    int64(info.Mode().Perm()),
+			Size:    info.Size(),
+			ModTime: staticSiteEpoch,
+			Format:  tar.FormatPAX,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.Copy(tw, f); err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.Close()
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// teardownSettle is how long an uninstall with a teardown priority waits for
+// the other uninstalls Terraform schedules in the same walk to register
+// before it decides whether it has to wait for them.
+const teardownSettle = 2 * time.Second
+
+// teardownScheduler orders concurrent app uninstalls by teardown_priority.
+// An uninstall only waits for registered uninstalls with a lower priority,
+// never for ones that have not started, so it cannot deadlock against the
+// order Terraform's dependency graph already imposes.
+type teardownScheduler struct {
+	mu      sync.Mutex
+	pending map[int64]int
+	changed chan struct{}
+}
+
+func newTeardownScheduler() *teardownScheduler {
+	return &teardownScheduler{pending: map[int64]int{}, changed: make(chan struct{})}
+}
+
+// acquire registers an uninstall with priority and blocks until no uninstall
+// with a lower priority is in progress. The returned function must be called
+// once the uninstall has finished.
+func (s *teardownScheduler) acquire(ctx context.Context, priority int64) (func(), error) {
+	s.mu.Lock()
+	s.pending[priority]++
+	s.notify()
+	s.mu.Unlock()
+	release := func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.pending[priority]--; s.pending[priority] == 0 {
+			delete(s.pending, priority)
+		}
+		s.notify()
+	}
+	if err := sleepContext(ctx, teardownSettle); err != nil {
+		release()
+		return nil, err
+	}
+	for {
+		s.mu.Lock()
+		blocked := false
+		for p := range s.pending {
+			if p < priority {
+				blocked = true
+				break
+			}
+		}
+		changed := s.changed
+		s.mu.Unlock()
+		if !blocked {
+			return release, nil
+		}
+		select {
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// notify wakes every waiter. s.mu must be held.
+func (s *teardownScheduler) notify() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
@@ -0,0 +1,146 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/flosch/pongo2/v6"
+)
+
+// Values of env.engine.
+const (
+	engineGoTemplate = "gotemplate"
+	engineJinja2     = "jinja2"
+)
+
+var templateEngines = []string{engineGoTemplate, engineJinja2}
+
+const defaultJinjaExtension = ".j2"
+
+func init() {
+	// Templates render configuration files rather than HTML, so values are
+	// written as they are.
+	pongo2.SetAutoescape(false)
+}
+
+// resolveEngine returns the env.engine template engine, defaulting to Go
+// templates.
+func resolveEngine(env *LPKBuildEnvModel) string {
+	if env == nil || env.Engine.ValueString() == "" {
+		return engineGoTemplate
+	}
+	return env.Engine.ValueString()
+}
+
+// renderJinja renders the Jinja2 template at path, whose contents are text,
+// with pongo2. Templates it includes, imports, or extends are resolved
+// relative to it and must lie within baseDir. An output tag printing an unset
+// variable, such as {{ KEY }}, follows onMissing; unset variables anywhere
+// else, e.g. in conditions or filters, are empty.
+func renderJinja(baseDir, path, text string, vars map[string]string, onMissing string) (string, error) {
+	ctx := make(pongo2.Context, len(vars))
+	for key, value := range vars {
+		ctx[key] = value
+	}
+	if onMissing != onMissingEmpty {
+		text = jinjaComment.ReplaceAllString(text, "")
+		bound := jinjaBoundNames(text)
+		for _, ref := range jinjaOutputRef.FindAllStringSubmatch(text, -1) {
+			name := ref[1]
+			if _, ok := ctx[name]; ok || bound[name] {
+				continue
+			}
+			if onMissing == onMissingError {
+				return "", fmt.Errorf("environment variable %s not provided", name)
+			}
+			ctx[name] = ref[0]
+		}
+	}
+	baseDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return "", err
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return "", err
+	}
+	set := pongo2.NewSet("lpk_build", jinjaLoader{baseDir: baseDir})
+	tmpl, err := set.FromFile(path)
+	if err != nil {
+		return "", err
+	}
+	return tmpl.Execute(ctx)
+}
+
+var (
+	// jinjaOutputRef matches an output tag printing one variable, such as
+	// {{ KEY }} or {{- KEY -}}.
+	jinjaOutputRef = regexp.MustCompile(`\{\{-?\s*([A-Za-z_][A-Za-z0-9_]*)\s*-?\}\}`)
+	jinjaComment   = regexp.MustCompile(`(?s)\{#.*?#\}`)
+	// jinjaBinding matches the tags that bind names of their own: the loop
+	// variables of for, set, the assignments of with, and macro parameters.
+	jinjaBinding = regexp.MustCompile(`\{%-?\s*(?:for\s+([A-Za-z0-9_,\s]+?)\s+in\s|set\s+([A-Za-z_][A-Za-z0-9_]*)\s*=|with\s+([^%]*?)-?%\}|macro\s+[A-Za-z_][A-Za-z0-9_]*\s*\(([^)]*)\))`)
+	jinjaIdent   = regexp.MustCompile(`[A-Za-z_][A-Za-z0-9_]*`)
+	jinjaAssign  = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\s*=|\bas\s+([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// jinjaBoundNames returns the names text binds itself, which are not
+// environment variables even when they are unset.
+func jinjaBoundNames(text string) map[string]bool {
+	bound := map[string]bool{}
+	for _, m := range jinjaBinding.FindAllStringSubmatch(text, -1) {
+		switch {
+		case m[1] != "":
+			for _, name := range jinjaIdent.FindAllString(m[1], -1) {
+				bound[name] = true
+			}
+		case m[2] != "":
+			bound[m[2]] = true
+		case m[3] != "":
+			for _, assign := range jinjaAssign.FindAllStringSubmatch(m[3], -1) {
+				bound[assign[1]+assign[2]] = true
+			}
+		case m[4] != "":
+			for _, param := range strings.Split(m[4], ",") {
+				name, _, _ := strings.Cut(param, "=")
+				bound[strings.TrimSpace(name)] = true
+			}
+		}
+	}
+	return bound
+}
+
+// jinjaLoader loads the templates pongo2 includes, imports, or extends,
+// refusing any that resolve outside baseDir, so a crafted repository cannot
+// read arbitrary files.
+type jinjaLoader struct {
+	baseDir string
+}
+
+func (l jinjaLoader) Abs(base, name string) string {
+	if filepath.IsAbs(name) {
+		return name
+	}
+	if base == "" {
+		return filepath.Join(l.baseDir, name)
+	}
+	return filepath.Join(filepath.Dir(base), name)
+}
+
+func (l jinjaLoader) Get(path string) (io.Reader, error) {
+	resolved, err := resolveWithin(l.baseDir, path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(resolved)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(data), nil
+}
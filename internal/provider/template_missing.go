@@ -0,0 +1,77 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"text/template"
+	"text/template/parse"
+)
+
+// Values of env.on_missing.
+const (
+	onMissingError = "error"
+	onMissingEmpty = "empty"
+	onMissingKeep  = "keep"
+)
+
+var onMissingModes = []string{onMissingError, onMissingEmpty, onMissingKeep}
+
+// resolveOnMissing returns the env.on_missing mode, defaulting to error.
+func resolveOnMissing(env *LPKBuildEnvModel) string {
+	if env == nil || env.OnMissing.ValueString() == "" {
+		return onMissingError
+	}
+	return env.OnMissing.ValueString()
+}
+
+// missingKeyOption returns the text/template missingkey option for mode.
+// Under keep, what keepMissing does not substitute, such as an unset
+// variable tested by if, is empty.
+func missingKeyOption(mode string) string {
+	if mode == onMissingError {
+		return "missingkey=error"
+	}
+	return "missingkey=zero"
+}
+
+// keepMissing returns envVars extended so every {{.KEY}} action of tmpl that
+// outputs an unset variable renders as the reference itself. Actions within
+// with and range, where dot is no longer the variables, are left alone.
+func keepMissing(tmpl *template.Template, envVars map[string]string) map[string]string {
+	vars := make(map[string]string, len(envVars))
+	for key, value := range envVars {
+		vars[key] = value
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			collectFieldRefs(t.Tree.Root, func(key string) {
+				if _, ok := vars[key]; !ok {
+					vars[key] = "{{." + key + "}}"
+				}
+			})
+		}
+	}
+	return vars
+}
+
+func collectFieldRefs(node parse.Node, add func(string)) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			collectFieldRefs(child, add)
+		}
+	case *parse.ActionNode:
+		if len(n.Pipe.Decl) > 0 || len(n.Pipe.Cmds) != 1 || len(n.Pipe.Cmds[0].Args) != 1 {
+			return
+		}
+		if field, ok := n.Pipe.Cmds[0].Args[0].(*parse.FieldNode); ok && len(field.Ident) == 1 {
+			add(field.Ident[0])
+		}
+	case *parse.IfNode:
+		collectFieldRefs(n.List, add)
+		collectFieldRefs(n.ElseList, add)
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	"github.com/hashicorp/terraform-plugin-framework-validators/stringvalidator"
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/schema/validator"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// TimeoutsModel describes the `timeouts` block of long-running resources.
+type TimeoutsModel struct {
+	Create types.String `tfsdk:"create"`
+	Update types.String `tfsdk:"update"`
+	Delete types.String `tfsdk:"delete"`
+}
+
+const (
+	defaultCreateTimeout = 20 * time.Minute
+	defaultUpdateTimeout = 20 * time.Minute
+	defaultDeleteTimeout = 10 * time.Minute
+)
+
+var durationPattern = regexp.MustCompile(`^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`)
+
+// timeoutsBlock returns the `timeouts` block bounding create, update, and
+// delete, including installs, builds, and uploads.
+func timeoutsBlock() schema.SingleNestedBlock {
+	attribute := func(op, def string) schema.StringAttribute {
+		return schema.StringAttribute{
+			MarkdownDescription: "Time allowed for " + op + " as a Go duration, e.g. `45m`. Defaults to `" + def + "`.",
+			Optional:            true,
+			Validators: []validator.String{
+				stringvalidator.RegexMatches(durationPattern, "must be a Go duration such as 30s or 1h30m"),
+			},
+		}
+	}
+	return schema.SingleNestedBlock{
+		MarkdownDescription: "Limits how long an operation may take. Per-request limits such as the provider `upload_timeout` still apply within it.",
+		Attributes: map[string]schema.Attribute{
+			"create": attribute("create", "20m"),
+			"update": attribute("update", "20m"),
+			"delete": attribute("delete", "10m"),
+		},
+	}
+}
+
+func (t *TimeoutsModel) create(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t == nil {
+		return context.WithTimeout(ctx, defaultCreateTimeout)
+	}
+	return context.WithTimeout(ctx, timeoutOr(t.Create, defaultCreateTimeout))
+}
+
+func (t *TimeoutsModel) update(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t == nil {
+		return context.WithTimeout(ctx, defaultUpdateTimeout)
+	}
+	return context.WithTimeout(ctx, timeoutOr(t.Update, defaultUpdateTimeout))
+}
+
+func (t *TimeoutsModel) delete(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t == nil {
+		return context.WithTimeout(ctx, defaultDeleteTimeout)
+	}
+	return context.WithTimeout(ctx, timeoutOr(t.Delete, defaultDeleteTimeout))
+}
+
+func timeoutOr(value types.String, def time.Duration) time.Duration {
+	if value.IsNull() || value.IsUnknown() {
+		return def
+	}
+	d, err := time.ParseDuration(value.ValueString())
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
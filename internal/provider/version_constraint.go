@@ -0,0 +1,165 @@
+// Copyright (c) HashiCorp, Inc.
+
+package provider
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// packageVersion is a dotted numeric version with an optional pre-release,
+// e.g. 1.4.2 or 2.0.0-beta.1.
+type packageVersion struct {
+	segments   []int
+	prerelease string
+}
+
+func parseVersion(raw string) (packageVersion, error) {
+	s := strings.TrimPrefix(strings.TrimSpace(raw), "v")
+	if i := strings.IndexByte(s, '+'); i >= 0 {
+		s = s[:i]
+	}
+	var v packageVersion
+	if i := strings.IndexByte(s, '-'); i >= 0 {
+		s, v.prerelease = s[:i], s[i+1:]
+	}
+	for _, part := range strings.Split(s, ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return packageVersion{}, fmt.Errorf("invalid version %q", raw)
+		}
+		v.segments = append(v.segments, n)
+	}
+	return v, nil
+}
+
+// compare returns -1, 0, or 1. Missing segments count as zero and a
+// pre-release sorts before its release.
+func (v packageVersion) compare(o packageVersion) int {
+	for i := 0; i < max(len(v.segments), len(o.segments)); i++ {
+		a, b := segment(v.segments, i), segment(o.segments, i)
+		if a != b {
+			if a < b {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case v.prerelease == o.prerelease:
+		return 0
+	case v.prerelease == "":
+		return 1
+	case o.prerelease == "":
+		return -1
+	case v.prerelease < o.prerelease:
+		return -1
+	default:
+		return 1
+	}
+}
+
+func segment(segments []int, i int) int {
+	if i < len(segments) {
+		return segments[i]
+	}
+	return 0
+}
+
+// versionConstraint is a comma-separated list of conditions that must all
+// hold, in the syntax of Terraform version constraints: =, !=, >, >=, <, <=,
+// and ~>, which allows only the rightmost given segment to increase.
+type versionConstraint []versionCondition
+
+type versionCondition struct {
+	op      string
+	version packageVersion
+}
+
+var constraintOperators = []string{">=", "<=", "!=", "~>", "=", ">", "<"}
+
+func parseConstraint(raw string) (versionConstraint, error) {
+	var constraint versionConstraint
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		op := "="
+		for _, candidate := range constraintOperators {
+			if strings.HasPrefix(part, candidate) {
+				op, part = candidate, strings.TrimSpace(strings.TrimPrefix(part, candidate))
+				break
+			}
+		}
+		version, err := parseVersion(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %q: %w", raw, err)
+		}
+		constraint = append(constraint, versionCondition{op: op, version: version})
+	}
+	return constraint, nil
+}
+
+// allows reports whether v meets every condition. Pre-releases only match
+// conditions that name one explicitly, so ~> 1.4 never picks 1.5.0-beta and
+// an empty constraint allows every release.
+func (c versionConstraint) allows(v packageVersion) bool {
+	if len(c) == 0 && v.prerelease != "" {
+		return false
+	}
+	for _, cond := range c {
+		if v.prerelease != "" && cond.version.prerelease == "" {
+			return false
+		}
+		cmp := v.compare(cond.version)
+		var ok bool
+		switch cond.op {
+		case "=":
+			ok = cmp == 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case ">=":
+			ok = cmp >= 0
+		case "<":
+			ok = cmp < 0
+		case "<=":
+			ok = cmp <= 0
+		case "~>":
+			ok = cmp >= 0 && withinPessimistic(v, cond.version)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// withinPessimistic reports whether v shares every segment of base but the
+// last given one, e.g. 1.4.x and 1.5 for ~> 1.4 but not 2.0.
+func withinPessimistic(v, base packageVersion) bool {
+	fixed := max(len(base.segments)-1, 1)
+	for i := 0; i < fixed; i++ {
+		if segment(v.segments, i) != segment(base.segments, i) {
+			return false
+		}
+	}
+	return true
+}
+
+// newestAllowed returns the index of the newest of versions c allows, or -1.
+// Versions that do not parse are skipped.
+func (c versionConstraint) newestAllowed(versions []string) int {
+	best := -1
+	var bestVersion packageVersion
+	for i, raw := range versions {
+		v, err := parseVersion(raw)
+		if err != nil || !c.allows(v) {
+			continue
+		}
+		if best < 0 || v.compare(bestVersion) > 0 {
+			best, bestVersion = i, v
+		}
+	}
+	return best
+}
@@ -37,6 +37,9 @@ func main() {
 	}
 
 	err := providerserver.Serve(context.Background(), provider.New(version), opts)
+	if closeErr := provider.CloseTunnels(); closeErr != nil {
+		log.Printf("closing ssh tunnels: %s", closeErr)
+	}
 
 	if err != nil {
 		log.Fatal(err.Error())